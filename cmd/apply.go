@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"hyprtrigger/internal/daemon"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Run all rules against the windows that are already open",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result daemon.ApplyResult
+		if err := daemon.Call("apply", nil, &result); err != nil {
+			return fmt.Errorf("apply failed: %w", err)
+		}
+		if result.Started {
+			fmt.Println("Applying rules to existing windows")
+		} else {
+			fmt.Println("Apply already in progress")
+		}
+		return nil
+	},
+}
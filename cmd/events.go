@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 
 	"github.com/spf13/cobra"
 	"hyprtrigger/internal/builtin"
@@ -57,9 +58,14 @@ func builtinEventsJSON() (string, error) {
 	var all []events.Event
 	for _, list := range r.GetBuiltinEvents() {
 		for _, ev := range list {
-			all = append(all, *ev)
+			// Without its id, an exported rule loads as a rule of its own
+			// instead of clashing with the builtin.
+			e := *ev
+			e.ID = ""
+			all = append(all, e)
 		}
 	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Position < all[j].Position })
 
 	cfg := struct {
 		Events []events.Event `json:"events"`
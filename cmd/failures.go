@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"hyprtrigger/internal/daemon"
+)
+
+var failuresCmd = &cobra.Command{
+	Use:   "failures",
+	Short: "Show recent rule failures of the running daemon",
+	Long: `Show recent rule failures of the running daemon.
+
+This is a shorthand for 'hyprtrigger history --failed'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result daemon.HistoryResult
+		if err := daemon.Call("history", daemon.HistoryParams{Failed: true}, &result); err != nil {
+			return fmt.Errorf("failures query failed: %w", err)
+		}
+		if len(result.Executions) == 0 {
+			fmt.Println("No recent failures")
+			return nil
+		}
+		fmt.Print(formatHistory(result.Executions))
+		return nil
+	},
+}
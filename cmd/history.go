@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"hyprtrigger/internal/daemon"
+	"hyprtrigger/internal/events"
+)
+
+var (
+	historyRule   string
+	historyFailed bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent action executions of the running daemon",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result daemon.HistoryResult
+		params := daemon.HistoryParams{Rule: historyRule, Failed: historyFailed}
+		if err := daemon.Call("history", params, &result); err != nil {
+			return fmt.Errorf("history query failed: %w", err)
+		}
+		fmt.Print(formatHistory(result.Executions))
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyRule, "rule", "", "Only show executions of this rule (its id or file#index, or a file for all its rules)")
+	historyCmd.Flags().BoolVar(&historyFailed, "failed", false, "Only show failed executions")
+}
+
+func formatHistory(list []events.Execution) string {
+	if len(list) == 0 {
+		return "No executions recorded\n"
+	}
+
+	var b strings.Builder
+	for _, e := range list {
+		fmt.Fprintf(&b, "%s  %s on %s  %s (%v, exit %d)\n",
+			e.Start.Local().Format(time.DateTime), e.Rule, e.Event, e.Action,
+			e.Duration.Round(time.Millisecond), e.ExitCode)
+		fmt.Fprintf(&b, "    $ %s\n", e.Command)
+		if e.Error != "" {
+			fmt.Fprintf(&b, "    error: %s\n", e.Error)
+		}
+		writeOutput(&b, "stdout", e.Stdout)
+		writeOutput(&b, "stderr", e.Stderr)
+	}
+	return b.String()
+}
+
+func writeOutput(b *strings.Builder, name, output string) {
+	if output == "" {
+		return
+	}
+	fmt.Fprintf(b, "    %s: %s\n", name, strings.ReplaceAll(output, "\n", "\n      "))
+}
@@ -35,8 +35,7 @@ var initConfigCmd = &cobra.Command{
     {
       "name": "openwindow",
       "regex": "calculator",
-      "command": "hyprctl --batch \"dispatch setfloating address:0x{WINDOW_ID}; dispatch centerwindow\"",
-      "use_shell": true
+      "dispatch": ["setfloating address:0x{WINDOW_ID}", "centerwindow"]
     }
   ]
 }`
@@ -11,9 +11,10 @@ var reloadCmd = &cobra.Command{
 	Use:   "reload",
 	Short: "Reload configuration in running daemon",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := daemon.SendReload(); err != nil {
+		if err := daemon.Call("reload", nil, nil); err != nil {
 			return fmt.Errorf("reload failed: %w", err)
 		}
+		fmt.Println("Configuration reloaded")
 		return nil
 	},
 }
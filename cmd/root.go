@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"hyprtrigger/internal/builtin"
@@ -18,6 +24,11 @@ var (
 	configPath   string
 	noBuiltin    bool
 	noAutoConfig bool
+	noWatch      bool
+	logHistory   bool
+
+	hyprlandSocket   string
+	hyprlandInstance string
 )
 
 var rootCmd = &cobra.Command{
@@ -39,12 +50,25 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Path to JSON config file or directory")
 	rootCmd.PersistentFlags().BoolVarP(&noBuiltin, "no-builtin", "n", false, "Disable builtin events")
 	rootCmd.PersistentFlags().BoolVarP(&noAutoConfig, "no-auto-config", "s", false, "Skip auto-loading from ~/.config/hyprtrigger/")
+	rootCmd.PersistentFlags().BoolVar(&noWatch, "no-watch", false, "Do not reload automatically when config files change")
+	rootCmd.PersistentFlags().BoolVar(&logHistory, "log-history", false, "Append every action execution to $XDG_STATE_HOME/hyprtrigger/history.jsonl")
+	rootCmd.PersistentFlags().StringVar(&hyprlandSocket, "hyprland-socket", "", "Path to Hyprland's .socket2.sock or instance directory")
+	rootCmd.PersistentFlags().StringVar(&hyprlandInstance, "instance", "", "Hyprland instance signature to connect to")
 
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(reloadCmd)
+	rootCmd.AddCommand(applyCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(shutdownCmd)
+	rootCmd.AddCommand(failuresCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(rulesCmd)
+	rootCmd.AddCommand(ruleCmd)
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(watchCmd)
 	rootCmd.AddCommand(initConfigCmd)
+	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(eventsCmd)
 }
 
@@ -59,9 +83,13 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 
 	fmt.Println("Starting Hyprland event monitor")
 
-	if err := loadConfig(); err != nil {
-		return err
+	// A broken rule or file must not keep every other rule from running;
+	// only reloads, which have rules to fall back on, are all or nothing.
+	registry, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Skipping invalid configuration: %v\n", err)
 	}
+	events.DefaultProcessor.SetRegistry(registry)
 
 	printEventsSummary()
 
@@ -69,34 +97,102 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no events loaded. Use -c to specify a config file or run 'hyprtrigger init-config'")
 	}
 
+	if logHistory {
+		path := filepath.Join(config.GetStateDirectory(), "history.jsonl")
+		if err := events.DefaultProcessor.History().SetFile(path); err != nil {
+			return err
+		}
+		fmt.Printf("Logging executions to %s\n", path)
+	}
+
+	client := hyprland.NewClient(hyprland.DiscoveryOptions{
+		Socket:   hyprlandSocket,
+		Instance: hyprlandInstance,
+	})
+
+	// Every method is registered before the socket opens, so status and
+	// the other commands work while the daemon is still connecting.
 	daemonServer := daemon.NewDaemon()
+	startedAt := time.Now()
+	daemonServer.Handle("status", func(json.RawMessage) (any, error) {
+		return daemonStatus(client, startedAt), nil
+	})
+	handleControl(daemonServer)
+	handleSubscribe(daemonServer)
+	daemonServer.Handle("rules", func(params json.RawMessage) (any, error) {
+		var p daemon.RulesParams
+		if err := daemon.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return daemon.RulesResult{Rules: liveRules(p)}, nil
+	})
+	daemonServer.Handle("history", func(params json.RawMessage) (any, error) {
+		var p daemon.HistoryParams
+		if err := daemon.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return daemon.HistoryResult{
+			Executions: events.DefaultProcessor.History().List(p.Rule, p.Failed),
+		}, nil
+	})
+
 	if err := daemonServer.Start(); err != nil {
 		return fmt.Errorf("failed to start daemon: %w", err)
 	}
 	defer daemonServer.Stop()
 
-	client := hyprland.NewClient()
 	if err := client.Connect(); err != nil {
 		return fmt.Errorf("%v\nMake sure Hyprland is running", err)
 	}
 	defer client.Close()
 
+	requests := hyprland.NewRequestClient()
+	events.DefaultProcessor.SetDispatcher(requests)
+
+	state := hyprland.NewState()
+	if err := state.Sync(requests); err != nil {
+		fmt.Printf("Window state not seeded: %v\n", err)
+	}
+	events.DefaultProcessor.SetStateTracker(state)
+
+	applyOnStart(requests, nil)
+
+	var configChanges <-chan struct{}
+	if !noWatch {
+		watcher, err := watchConfig()
+		if err != nil {
+			fmt.Printf("Config watching disabled: %v\n", err)
+		} else {
+			defer watcher.Close()
+			configChanges = watcher.Changes()
+		}
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	listenerDone := make(chan error, 1)
-	go func() {
-		listenerDone <- hyprland.NewListener(client).Listen()
-	}()
+	listener := hyprland.NewListener(client)
+	listener.OnReconnect(func() {
+		if err := state.Sync(requests); err != nil {
+			fmt.Printf("Window state resync failed: %v\n", err)
+		}
+	})
+	go listener.Run()
 
 	for {
 		select {
-		case <-daemonServer.GetReloadChannel():
+		case reply := <-daemonServer.GetReloadChannel():
 			fmt.Println("Reloading configuration...")
-			if err := reloadConfig(); err != nil {
-				fmt.Printf("Reload failed: %v\n", err)
-			} else {
-				printEventsSummary()
+			reply <- reloadError(handleReload(requests))
+
+		case <-configChanges:
+			fmt.Println("Config changed, reloading...")
+			handleReload(requests)
+
+		case <-daemonServer.GetApplyChannel():
+			fmt.Println("Applying rules to existing windows...")
+			if err := hyprland.ApplyToExistingWindows(requests, events.DefaultProcessor, nil); err != nil {
+				fmt.Printf("Apply failed: %v\n", err)
 			}
 
 		case <-daemonServer.GetShutdownChannel():
@@ -106,48 +202,118 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 		case sig := <-sigChan:
 			fmt.Printf("\nReceived signal: %v\n", sig)
 			return nil
-
-		case err := <-listenerDone:
-			return err
 		}
 	}
 }
 
-func loadConfig() error {
+// loadConfig builds a complete registry from builtins, auto-config and -c.
+// Nothing is published; the caller hands the result to the processor. On
+// error the registry is still returned, holding every rule that loaded.
+func loadConfig() (*events.Registry, error) {
+	// Builtins are registered even when skipped, so overrides by id still
+	// find them.
+	r := events.NewRegistry()
 	if noBuiltin {
 		fmt.Println("Builtin events disabled")
-		events.DefaultRegistry.SetSkipBuiltinEvents(true)
-	} else {
-		builtin.Register(events.DefaultRegistry)
 	}
+	r.SetSkipBuiltinEvents(noBuiltin)
+	builtin.Register(r)
 
+	var errs []error
 	if !noAutoConfig {
-		if err := config.LoadAutoConfig(); err != nil {
-			fmt.Printf("Auto-config loading failed: %v\n", err)
+		if err := config.LoadAutoConfig(r); err != nil {
+			errs = append(errs, err)
 		}
 	}
 
 	if configPath != "" {
 		fmt.Printf("Loading config: %s\n", configPath)
-		if err := config.LoadEventsFromPath(configPath); err != nil {
-			return fmt.Errorf("config loading failed: %w", err)
+		if err := config.LoadEventsFromPath(r, configPath); err != nil {
+			errs = append(errs, err)
 		}
 	}
 
-	return nil
+	if len(errs) > 0 {
+		return r, fmt.Errorf("config loading failed: %w", errors.Join(errs...))
+	}
+	return r, nil
 }
 
+// reloadConfig loads and validates the configuration into a new registry
+// and swaps it in atomically. On any error the running rules are kept.
 func reloadConfig() error {
-	events.DefaultRegistry.Clear()
-	if err := loadConfig(); err != nil {
+	r, err := loadConfig()
+	if err != nil {
 		return err
 	}
-	if len(events.GetAllEvents()) == 0 {
+	if len(r.GetAllEvents()) == 0 {
 		return fmt.Errorf("no events loaded after reload")
 	}
+	events.DefaultProcessor.SetRegistry(r)
 	return nil
 }
 
+// handleReload is the reload path shared by the socket command and the
+// config watcher.
+func handleReload(requests *hyprland.RequestClient) error {
+	old := events.DefaultProcessor.Registry()
+	if err := reloadConfig(); err != nil {
+		fmt.Printf("Reload failed, keeping current rules: %v\n", err)
+		return err
+	}
+	printEventsSummary()
+	applyOnStart(requests, old)
+	return nil
+}
+
+// reloadError turns a failed reload into a protocol error that carries the
+// configuration problems, so clients can show them with their positions.
+func reloadError(err error) error {
+	if err == nil {
+		return nil
+	}
+	rpcErr := daemon.NewError(daemon.CodeReloadFailed, "keeping current rules: %v", err)
+	if problems := config.Problems(err); len(problems) > 0 {
+		rpcErr.Data = problems
+	}
+	return rpcErr
+}
+
+// watchConfig watches the auto-config directory and the -c path, which is
+// scanned recursively when it is a directory, like LoadEventsFromDirectory.
+func watchConfig() (*config.Watcher, error) {
+	watcher, err := config.NewWatcher(300 * time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	if !noAutoConfig {
+		if err := watcher.Watch(config.GetConfigDirectory(), false); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+	if configPath != "" {
+		if err := watcher.Watch(configPath, true); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+	return watcher, nil
+}
+
+// applyOnStart runs the rules marked apply_on_start against the windows
+// that are already open. After a reload, old is the previous rule set and
+// only the rules added or changed since are run, so saving a config file
+// does not re-apply every rule.
+func applyOnStart(requests *hyprland.RequestClient, old *events.Registry) {
+	onStart := func(ev *events.Event) bool {
+		return ev.ApplyOnStart && (old == nil || !old.Contains(ev))
+	}
+	if err := hyprland.ApplyToExistingWindows(requests, events.DefaultProcessor, onStart); err != nil {
+		fmt.Printf("Apply on start failed: %v\n", err)
+	}
+}
+
 func printEventsSummary() {
 	allEvents := events.GetAllEvents()
 	if len(allEvents) == 0 {
@@ -155,18 +321,51 @@ func printEventsSummary() {
 		return
 	}
 
+	names := make([]string, 0, len(allEvents))
+	for name := range allEvents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	fmt.Println("\nLoaded events:")
 	total := 0
-	for name, list := range allEvents {
+	for _, name := range names {
+		list := allEvents[name]
 		fmt.Printf("  %s: %d event(s)\n", name, len(list))
 		for _, ev := range list {
-			cmd := ev.Command
+			cmd := actionSummary(ev)
 			if len(cmd) > 50 {
 				cmd = cmd[:47] + "..."
 			}
-			fmt.Printf("    - regex: %-30s  cmd: %s\n", ev.Regex, cmd)
+			flags := ""
+			if ev.ID != "" {
+				flags += "  id: " + ev.ID
+			}
+			if ev.Priority != 0 {
+				flags += fmt.Sprintf("  priority: %d", ev.Priority)
+			}
+			if ev.Final {
+				flags += "  final"
+			}
+			if !ev.IsEnabled() {
+				flags += "  disabled"
+			}
+			fmt.Printf("    - match: %-30s  cmd: %s%s\n", ev.MatchSummary(), cmd, flags)
 		}
 		total += len(list)
 	}
 	fmt.Printf("Total: %d event(s)\n\n", total)
 }
+
+// actionSummary renders what a rule runs: its command, or else its dispatch
+// list.
+func actionSummary(ev *events.Event) string {
+	switch {
+	case len(ev.Argv) > 0:
+		return strings.Join(ev.Argv, " ")
+	case ev.Command != "":
+		return ev.Command
+	default:
+		return "dispatch " + strings.Join(ev.Dispatch, "; ")
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"hyprtrigger/internal/daemon"
+	"hyprtrigger/internal/events"
+)
+
+var controlFor time.Duration
+
+var ruleCmd = &cobra.Command{
+	Use:   "rule",
+	Short: "Switch rules of the running daemon on and off",
+}
+
+var ruleDisableCmd = &cobra.Command{
+	Use:   "disable <id>",
+	Short: "Stop a rule from firing until enabled again or --for expires",
+	Long: `Stop a rule from firing until enabled again or --for expires.
+
+The rule is named by its id, or by file#index when it has none (see
+'hyprtrigger rules'). The setting is kept in memory only; it survives
+reloads but not a daemon restart.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result daemon.ControlResult
+		params := daemon.RuleControlParams{Rule: args[0], For: events.Duration(controlFor)}
+		if err := daemon.Call("rule.disable", params, &result); err != nil {
+			return fmt.Errorf("disable failed: %w", err)
+		}
+		fmt.Printf("Rule %s disabled %s\n", args[0], untilText(result.Until, "enabled"))
+		return nil
+	},
+}
+
+var ruleEnableCmd = &cobra.Command{
+	Use:   "enable <id>",
+	Short: "Let a rule disabled with 'rule disable' fire again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result daemon.ControlResult
+		if err := daemon.Call("rule.enable", daemon.RuleControlParams{Rule: args[0]}, &result); err != nil {
+			return fmt.Errorf("enable failed: %w", err)
+		}
+		if result.Changed {
+			fmt.Printf("Rule %s enabled\n", args[0])
+		} else {
+			fmt.Printf("Rule %s was not disabled\n", args[0])
+		}
+		return nil
+	},
+}
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Stop all rules from firing until resumed or --for expires",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result daemon.ControlResult
+		if err := daemon.Call("pause", daemon.PauseParams{For: events.Duration(controlFor)}, &result); err != nil {
+			return fmt.Errorf("pause failed: %w", err)
+		}
+		fmt.Printf("Paused %s\n", untilText(result.Until, "resumed"))
+		return nil
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Let rules fire again after pause",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result daemon.ControlResult
+		if err := daemon.Call("resume", nil, &result); err != nil {
+			return fmt.Errorf("resume failed: %w", err)
+		}
+		if result.Changed {
+			fmt.Println("Resumed")
+		} else {
+			fmt.Println("Not paused")
+		}
+		return nil
+	},
+}
+
+func init() {
+	ruleDisableCmd.Flags().DurationVar(&controlFor, "for", 0, "Re-enable the rule automatically after this long, e.g. 1h")
+	pauseCmd.Flags().DurationVar(&controlFor, "for", 0, "Resume automatically after this long, e.g. 30m")
+	ruleCmd.AddCommand(ruleDisableCmd)
+	ruleCmd.AddCommand(ruleEnableCmd)
+}
+
+// untilText renders an expiry, or otherwise when a switch without expiry
+// ends.
+func untilText(until time.Time, otherwise string) string {
+	if until.IsZero() {
+		return "until " + otherwise
+	}
+	return "until " + until.Local().Format(time.DateTime)
+}
+
+// handleControl registers the socket methods behind the rule, pause and
+// resume commands.
+func handleControl(d *daemon.Daemon) {
+	p := events.DefaultProcessor
+
+	d.Handle("rule.disable", func(params json.RawMessage) (any, error) {
+		var rp daemon.RuleControlParams
+		if err := daemon.DecodeParams(params, &rp); err != nil {
+			return nil, err
+		}
+		ev := findRule(rp.Rule)
+		if ev == nil {
+			return nil, daemon.NewError(daemon.CodeInvalidParams, "no rule %q", rp.Rule)
+		}
+		until := p.DisableRule(ev, time.Duration(rp.For))
+		return daemon.ControlResult{Changed: true, Until: until}, nil
+	})
+	d.Handle("rule.enable", func(params json.RawMessage) (any, error) {
+		var rp daemon.RuleControlParams
+		if err := daemon.DecodeParams(params, &rp); err != nil {
+			return nil, err
+		}
+		ev := findRule(rp.Rule)
+		if ev == nil {
+			return nil, daemon.NewError(daemon.CodeInvalidParams, "no rule %q", rp.Rule)
+		}
+		return daemon.ControlResult{Changed: p.EnableRule(ev)}, nil
+	})
+	d.Handle("pause", func(params json.RawMessage) (any, error) {
+		var pp daemon.PauseParams
+		if err := daemon.DecodeParams(params, &pp); err != nil {
+			return nil, err
+		}
+		return daemon.ControlResult{Changed: true, Until: p.Pause(time.Duration(pp.For))}, nil
+	})
+	d.Handle("resume", func(json.RawMessage) (any, error) {
+		return daemon.ControlResult{Changed: p.Resume()}, nil
+	})
+}
+
+// findRule looks a rule of the live rule set up by identity.
+func findRule(rule string) *events.Event {
+	for _, list := range events.DefaultProcessor.Registry().GetAllEvents() {
+		for _, ev := range list {
+			if ev.Identity() == rule {
+				return ev
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"hyprtrigger/internal/daemon"
+	"hyprtrigger/internal/events"
+)
+
+var (
+	rulesEvent  string
+	rulesOrigin string
+	rulesJSON   bool
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "List the rules loaded in the running daemon",
+	Long: `List the rules loaded in the running daemon, after builtins,
+auto-config, -c and reloads, in the order they are evaluated.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result daemon.RulesResult
+		params := daemon.RulesParams{Event: rulesEvent, Origin: rulesOrigin}
+		if err := daemon.Call("rules", params, &result); err != nil {
+			return fmt.Errorf("rules query failed: %w", err)
+		}
+
+		if rulesJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result.Rules)
+		}
+		printRules(result.Rules)
+		return nil
+	},
+}
+
+func init() {
+	rulesCmd.Flags().StringVar(&rulesEvent, "event", "", "Only list rules for this event")
+	rulesCmd.Flags().StringVar(&rulesOrigin, "origin", "", `Only list rules from this file (path, base name or directory) or "builtin"`)
+	rulesCmd.Flags().BoolVar(&rulesJSON, "json", false, "Print the rules as JSON")
+}
+
+// liveRules lists the daemon's current rules matching p, by event name and
+// then in evaluation order.
+func liveRules(p daemon.RulesParams) []daemon.RuleInfo {
+	all := events.DefaultProcessor.Registry().GetAllEvents()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rules := []daemon.RuleInfo{}
+	for _, name := range names {
+		if p.Event != "" && name != p.Event {
+			continue
+		}
+		for _, ev := range all[name] {
+			if p.Origin != "" && !originMatches(ev.Origin, p.Origin) {
+				continue
+			}
+			disabled, until := events.DefaultProcessor.RuleDisabled(ev)
+			rules = append(rules, daemon.RuleInfo{
+				ID:            ev.ID,
+				Rule:          ev.Identity(),
+				Event:         ev.Name,
+				Origin:        ev.Origin,
+				Position:      ev.Position,
+				Enabled:       ev.IsEnabled(),
+				Priority:      ev.Priority,
+				Final:         ev.Final,
+				Match:         ev.MatchSummary(),
+				Action:        actionSummary(ev),
+				Disabled:      disabled,
+				DisabledUntil: until,
+				RuleStats:     events.DefaultProcessor.RuleStats(ev.Identity()),
+			})
+		}
+	}
+	return rules
+}
+
+func originMatches(origin, filter string) bool {
+	if origin == filter || filepath.Base(origin) == filter {
+		return true
+	}
+	dir := strings.TrimSuffix(filter, "/") + "/"
+	return strings.HasPrefix(origin, dir)
+}
+
+func printRules(rules []daemon.RuleInfo) {
+	if len(rules) == 0 {
+		fmt.Println("No rules")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEVENT\tORIGIN\tENABLED\tPRIORITY\tFIRED\tFAILED\tSKIPPED\tLAST FIRED\tMATCH")
+	for _, r := range rules {
+		lastFired := "-"
+		if !r.LastFired.IsZero() {
+			lastFired = r.LastFired.Local().Format(time.DateTime)
+		}
+		id := r.ID
+		if id == "" {
+			id = "-"
+		}
+		priority := fmt.Sprint(r.Priority)
+		if r.Final {
+			priority += " final"
+		}
+		enabled := fmt.Sprint(r.Enabled)
+		if r.Disabled {
+			enabled = "off"
+			if !r.DisabledUntil.IsZero() {
+				enabled += " until " + r.DisabledUntil.Local().Format(time.TimeOnly)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s#%d\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n",
+			id, r.Event, r.Origin, r.Position, enabled, priority,
+			r.Fired, r.Failures, r.Skipped, lastFired, r.Match)
+	}
+	w.Flush()
+}
@@ -11,9 +11,10 @@ var shutdownCmd = &cobra.Command{
 	Use:   "shutdown",
 	Short: "Stop the running daemon",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := daemon.SendShutdown(); err != nil {
+		if err := daemon.Call("shutdown", nil, nil); err != nil {
 			return fmt.Errorf("shutdown failed: %w", err)
 		}
+		fmt.Println("Shutting down")
 		return nil
 	},
 }
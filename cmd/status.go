@@ -1,19 +1,138 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"hyprtrigger/internal/daemon"
+	"hyprtrigger/internal/events"
+	"hyprtrigger/internal/hyprland"
 )
 
+var statusJSON bool
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show status of running daemon",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := daemon.SendStatus(); err != nil {
+		var status daemon.StatusResult
+		if err := daemon.Call("status", nil, &status); err != nil {
 			return fmt.Errorf("status check failed: %w", err)
 		}
+
+		if statusJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(status)
+		}
+		printStatus(status)
 		return nil
 	},
 }
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Print the status as JSON")
+}
+
+// daemonStatus gathers the status reply in the daemon.
+func daemonStatus(client *hyprland.Client, startedAt time.Time) daemon.StatusResult {
+	registry := events.DefaultProcessor.Registry()
+	status := daemon.StatusResult{
+		PID:       os.Getpid(),
+		Version:   version,
+		StartedAt: startedAt,
+		Uptime:    events.Duration(time.Since(startedAt).Round(time.Second)),
+		Config: daemon.ConfigStatus{
+			Builtins: !noBuiltin,
+			Sources:  registry.Sources(),
+			LoadedAt: registry.Created(),
+		},
+		Stats: events.DefaultProcessor.Stats(),
+	}
+	status.Paused, status.PausedUntil = events.DefaultProcessor.Paused()
+
+	status.Hyprland.Connected, status.Hyprland.Since = client.Status()
+	if inst, ok := hyprland.CurrentInstance(); ok {
+		status.Hyprland.Instance = inst.Signature
+		if inst.Signature == "" {
+			status.Hyprland.Instance = inst.EventSocket()
+		}
+	}
+
+	for name, list := range registry.GetAllEvents() {
+		count := daemon.RuleCount{Event: name}
+		for _, ev := range list {
+			switch {
+			case !ev.IsEnabled():
+				count.Disabled++
+			case ev.Origin == "builtin":
+				count.Builtin++
+			default:
+				count.User++
+			}
+		}
+		status.Rules = append(status.Rules, count)
+	}
+	sort.Slice(status.Rules, func(i, j int) bool { return status.Rules[i].Event < status.Rules[j].Event })
+	return status
+}
+
+func printStatus(s daemon.StatusResult) {
+	const layout = time.DateTime
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Daemon:\trunning, pid %d, version %s\n", s.PID, s.Version)
+	if s.Paused {
+		fmt.Fprintf(w, "Paused:\t%s\n", untilText(s.PausedUntil, "resumed"))
+	}
+	fmt.Fprintf(w, "Uptime:\t%v (since %s)\n", time.Duration(s.Uptime), s.StartedAt.Local().Format(layout))
+
+	sources := s.Config.Sources
+	if s.Config.Builtins {
+		sources = append([]string{"builtin"}, sources...)
+	}
+	fmt.Fprintf(w, "Config:\tloaded %s from %s\n", s.Config.LoadedAt.Local().Format(layout), strings.Join(sources, ", "))
+
+	state := "disconnected"
+	if s.Hyprland.Connected {
+		state = "connected"
+	}
+	fmt.Fprintf(w, "Hyprland:\t%s since %s", state, s.Hyprland.Since.Local().Format(layout))
+	if s.Hyprland.Instance != "" {
+		fmt.Fprintf(w, " (instance %s)", s.Hyprland.Instance)
+	}
+	fmt.Fprintln(w)
+
+	st := s.Stats
+	fmt.Fprintf(w, "Fired:\t%d\n", st.Fired)
+	fmt.Fprintf(w, "Failures:\t%d\n", st.Failures)
+	fmt.Fprintf(w, "Suppressed:\t%d by dedupe, %d by throttle\n", st.Suppressed, st.Throttled)
+	fmt.Fprintf(w, "Skipped:\t%d while disabled or paused\n", st.Skipped)
+	if e := st.LastError; e != nil {
+		fmt.Fprintf(w, "Last error:\t%s  %s on %s: %s\n", e.Start.Local().Format(layout), e.Rule, e.Event, e.Error)
+	}
+
+	fmt.Fprintln(w, "\nEVENT\tBUILTIN\tUSER\tDISABLED\tRECEIVED")
+	seen := make(map[string]bool)
+	for _, rc := range s.Rules {
+		seen[rc.Event] = true
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", rc.Event, rc.Builtin, rc.User, rc.Disabled, st.Received[rc.Event])
+	}
+	var others []string
+	for name := range st.Received {
+		if !seen[name] {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	for _, name := range others {
+		fmt.Fprintf(w, "%s\t-\t-\t-\t%d\n", name, st.Received[name])
+	}
+	w.Flush()
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"hyprtrigger/internal/builtin"
+	"hyprtrigger/internal/config"
+	"hyprtrigger/internal/events"
+)
+
+var validateJSON bool
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Check configuration files without starting the daemon",
+	Long: `Check configuration files without starting the daemon.
+
+With a path, validate that file or every *.json file below that directory.
+Without one, validate what the daemon would load: ~/.config/hyprtrigger/
+(unless --no-auto-config) and the --config path.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := validateFiles(args)
+		if err != nil {
+			return err
+		}
+
+		// Files are checked in load order against the rules before them, so
+		// overrides by id resolve like they do in the daemon.
+		r := events.NewRegistry()
+		r.SetSkipBuiltinEvents(noBuiltin)
+		builtin.Register(r)
+
+		problems := []config.Problem{}
+		for _, file := range files {
+			rules, err := config.ParseFile(file, r)
+			if err != nil {
+				var loadErr *config.LoadError
+				if !errors.As(err, &loadErr) {
+					return err
+				}
+				problems = append(problems, loadErr.Problems...)
+			}
+			for i := range rules {
+				r.RegisterExplicit(&rules[i])
+			}
+		}
+
+		if validateJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(problems); err != nil {
+				return err
+			}
+		} else {
+			for _, p := range problems {
+				fmt.Println(p)
+			}
+			if len(problems) == 0 {
+				fmt.Printf("%d file(s) OK\n", len(files))
+			}
+		}
+
+		if len(problems) > 0 {
+			return fmt.Errorf("%d problem(s) in configuration", len(problems))
+		}
+		return nil
+	},
+}
+
+func init() {
+	validateCmd.Flags().BoolVar(&validateJSON, "json", false, "Print problems as a JSON array")
+}
+
+func validateFiles(args []string) ([]string, error) {
+	if len(args) == 1 {
+		return config.ListFiles(args[0])
+	}
+
+	var files []string
+	if !noAutoConfig {
+		auto, err := config.AutoConfigFiles()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, auto...)
+	}
+	if configPath != "" {
+		list, err := config.ListFiles(configPath)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, list...)
+	}
+	return files, nil
+}
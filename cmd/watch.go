@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"hyprtrigger/internal/daemon"
+	"hyprtrigger/internal/events"
+)
+
+// subscribeBuffer is how many events a watcher may lag behind before
+// events are dropped for it.
+const subscribeBuffer = 256
+
+var (
+	watchEvent string
+	watchMatch string
+	watchJSON  bool
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream the events seen by the running daemon and what the rules did",
+	Long: `Stream the events seen by the running daemon and what the rules did.
+
+Each event is shown with its raw socket2 line, its parsed fields and every
+rule that matched it, with the outcome: fired, debounced, suppressed,
+throttled, skipped or error. Stop with Ctrl+C.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		params := daemon.SubscribeParams{Event: watchEvent, Match: watchMatch}
+		c, err := daemon.Dial()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		enc := json.NewEncoder(os.Stdout)
+		err = c.Stream("subscribe", params, func(method string, raw json.RawMessage) error {
+			if method != "event" {
+				return nil
+			}
+			if watchJSON {
+				return enc.Encode(raw)
+			}
+			var ev daemon.WatchEvent
+			if err := json.Unmarshal(raw, &ev); err != nil {
+				return fmt.Errorf("invalid event: %w", err)
+			}
+			fmt.Print(formatWatchEvent(ev))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("watch failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringVar(&watchEvent, "event", "", "Only show events with this name")
+	watchCmd.Flags().StringVar(&watchMatch, "match", "", "Only show events whose raw line matches this regex")
+	watchCmd.Flags().BoolVar(&watchJSON, "json", false, "Print each event as a line of JSON")
+}
+
+func formatWatchEvent(ev daemon.WatchEvent) string {
+	var b strings.Builder
+	if ev.Dropped > 0 {
+		fmt.Fprintf(&b, "... %d event(s) dropped\n", ev.Dropped)
+	}
+	replay := ""
+	if ev.Replay {
+		replay = "  (replay)"
+	}
+	fmt.Fprintf(&b, "%s  %s%s\n", ev.Time.Local().Format(time.TimeOnly), ev.Raw, replay)
+
+	names := make([]string, 0, len(ev.Fields))
+	for name := range ev.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "    %s: %s\n", name, ev.Fields[name])
+	}
+
+	for _, r := range ev.Rules {
+		fmt.Fprintf(&b, "    -> %s: %s", r.Rule, r.Outcome)
+		if r.Error != "" {
+			fmt.Fprintf(&b, " (%s)", r.Error)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// handleSubscribe registers the socket method behind the watch command.
+// Every watcher gets its own buffered subscription, so a slow one only
+// loses events itself.
+func handleSubscribe(d *daemon.Daemon) {
+	d.HandleStream("subscribe", func(params json.RawMessage) (daemon.StreamFunc, error) {
+		var sp daemon.SubscribeParams
+		if err := daemon.DecodeParams(params, &sp); err != nil {
+			return nil, err
+		}
+		var match *regexp.Regexp
+		if sp.Match != "" {
+			re, err := regexp.Compile(sp.Match)
+			if err != nil {
+				return nil, daemon.NewError(daemon.CodeInvalidParams, "invalid match regex: %v", err)
+			}
+			match = re
+		}
+
+		filter := func(trace *events.Trace) bool {
+			return (sp.Event == "" || trace.Event == sp.Event) &&
+				(match == nil || match.MatchString(trace.Raw))
+		}
+		return func(send func(string, any) error, done <-chan struct{}) {
+			sub := events.DefaultProcessor.Subscribe(subscribeBuffer, filter)
+			defer sub.Close()
+			for {
+				select {
+				case <-done:
+					return
+				case trace := <-sub.C:
+					if err := send("event", daemon.WatchEvent{Trace: trace, Dropped: sub.Dropped()}); err != nil {
+						return
+					}
+				}
+			}
+		}, nil
+	})
+}
@@ -4,9 +4,13 @@ import "hyprtrigger/internal/events"
 
 func registerBitwarden(r *events.Registry) {
 	r.RegisterBuiltin(&events.Event{
-		Name:     "windowtitlev2",
-		Regex:    "Bitwarden Password Manager",
-		Command:  `hyprctl --batch "dispatch setfloating address:0x{WINDOW_ID}; dispatch resizewindowpixel exact 20% 50%, address:0x{WINDOW_ID}; dispatch centerwindow"`,
-		UseShell: true,
+		ID:    "builtin.bitwarden",
+		Name:  "windowtitlev2",
+		Regex: "Bitwarden Password Manager",
+		Dispatch: []string{
+			"setfloating address:0x{WINDOW_ID}",
+			"resizewindowpixel exact 20% 50%, address:0x{WINDOW_ID}",
+			"centerwindow",
+		},
 	})
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"hyprtrigger/internal/events"
 	"os"
 	"path/filepath"
 )
@@ -14,33 +15,53 @@ func GetConfigDirectory() string {
 	return filepath.Join(homeDir, ".config", "hyprtrigger")
 }
 
-func LoadAutoConfig() error {
+// GetStateDirectory returns $XDG_STATE_HOME/hyprtrigger, defaulting to
+// ~/.local/state/hyprtrigger.
+func GetStateDirectory() string {
+	if stateHome := os.Getenv("XDG_STATE_HOME"); stateHome != "" {
+		return filepath.Join(stateHome, "hyprtrigger")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".local", "state", "hyprtrigger")
+}
+
+// AutoConfigFiles lists the *.json files directly inside the config
+// directory. A missing directory yields no files.
+func AutoConfigFiles() ([]string, error) {
 	configDir := GetConfigDirectory()
 
 	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		return nil
+		return nil, nil
 	}
 
 	files, err := filepath.Glob(filepath.Join(configDir, "*.json"))
 	if err != nil {
-		return fmt.Errorf("failed to scan config directory: %w", err)
+		return nil, fmt.Errorf("failed to scan config directory: %w", err)
+	}
+	return files, nil
+}
+
+func LoadAutoConfig(r *events.Registry) error {
+	files, err := AutoConfigFiles()
+	if err != nil {
+		return err
 	}
 
 	if len(files) == 0 {
 		return nil
 	}
 
-	fmt.Printf("Auto-loading from: %s\n", configDir)
+	fmt.Printf("Auto-loading from: %s\n", GetConfigDirectory())
 
-	loaded := 0
+	before := len(r.Sources())
+	loadErr := &LoadError{}
 	for _, file := range files {
-		if err := LoadEventsFromFile(file); err != nil {
-			fmt.Printf("Failed to load %s: %v\n", filepath.Base(file), err)
-			continue
-		}
-		loaded++
+		loadErr.merge(file, LoadEventsFromFile(r, file))
 	}
 
-	fmt.Printf("Auto-loaded %d file(s)\n", loaded)
-	return nil
+	fmt.Printf("Auto-loaded %d file(s)\n", len(r.Sources())-before)
+	return loadErr.orNil()
 }
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Problem is one error found while loading configuration. Rule is the
+// index in the file's "events" array, or -1 for problems with the file
+// itself. Line and Column are 1-based and zero when unknown.
+type Problem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Rule    int    `json:"rule"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	var b strings.Builder
+	b.WriteString(p.File)
+	if p.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", p.Line, p.Column)
+	}
+	if p.Rule >= 0 {
+		fmt.Fprintf(&b, ": events[%d]", p.Rule)
+		if p.Field != "" {
+			b.WriteString("." + p.Field)
+		}
+	}
+	b.WriteString(": " + p.Message)
+	return b.String()
+}
+
+// LoadError collects every problem found in a configuration. Loaders keep
+// going after a problem so the whole list can be reported at once.
+type LoadError struct {
+	Problems []Problem
+}
+
+func (e *LoadError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = p.String()
+	}
+	return fmt.Sprintf("%d configuration problem(s):\n  %s", len(e.Problems), strings.Join(lines, "\n  "))
+}
+
+func (e *LoadError) add(p Problem) {
+	e.Problems = append(e.Problems, p)
+}
+
+// merge appends the problems of err, which is either a *LoadError or a
+// plain error about file.
+func (e *LoadError) merge(file string, err error) {
+	if err == nil {
+		return
+	}
+	if le, ok := err.(*LoadError); ok {
+		e.Problems = append(e.Problems, le.Problems...)
+		return
+	}
+	e.add(Problem{File: file, Rule: -1, Message: err.Error()})
+}
+
+func (e *LoadError) orNil() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Problems returns the problems of every *LoadError found in err's tree,
+// including errors joined with errors.Join.
+func Problems(err error) []Problem {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *LoadError:
+		return e.Problems
+	case interface{ Unwrap() []error }:
+		var problems []Problem
+		for _, inner := range e.Unwrap() {
+			problems = append(problems, Problems(inner)...)
+		}
+		return problems
+	case interface{ Unwrap() error }:
+		return Problems(e.Unwrap())
+	}
+	return nil
+}
@@ -2,78 +2,271 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hyprtrigger/internal/events"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-func LoadEventsFromFile(filename string) error {
+// ParseFile reads and validates a config file without registering
+// anything. On failure it returns a *LoadError whose problems carry line
+// and column positions, along with the rules that have no problem, so the
+// caller can choose to go on without the others. The rules' Origin and
+// Position are set.
+//
+// An entry with an id but no name overrides the rule registered under that
+// id in base, or defined earlier in the file: the keys it sets replace the
+// rule's, the others are kept. ParseFile returns the merged rule in its
+// place, ready to be registered over the original.
+func ParseFile(filename string, base *events.Registry) ([]events.Event, error) {
+	loadErr := &LoadError{}
+
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", filename, err)
+		loadErr.merge(filename, fmt.Errorf("failed to read file: %w", err))
+		return nil, loadErr
 	}
 
-	var cfg EventConfig
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return fmt.Errorf("failed to parse JSON %s: %w", filename, err)
+	// Only the file's structure is decoded here; each rule is decoded on its
+	// own below, so a bad value only invalidates the rule it is in.
+	var raw struct {
+		Events []json.RawMessage `json:"events"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		p := Problem{File: filename, Rule: -1, Message: "failed to parse JSON: " + err.Error()}
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			p.Line, p.Column = lineColumn(data, int(syntaxErr.Offset))
+		case errors.As(err, &typeErr):
+			p.Line, p.Column = lineColumn(data, int(typeErr.Offset))
+		}
+		loadErr.add(p)
+		return nil, loadErr
 	}
 
-	fmt.Printf("Loading %d event(s) from %s\n", len(cfg.Events), filename)
+	loc := newLocator(data)
+	if err := loc.scan(reflect.TypeOf(EventConfig{})); err != nil {
+		loadErr.merge(filename, fmt.Errorf("failed to parse JSON: %w", err))
+		return nil, loadErr
+	}
 
-	for _, event := range cfg.Events {
-		if event.Name == "" || event.Regex == "" || event.Command == "" {
-			fmt.Printf("Invalid event ignored in %s\n", filename)
-			continue
+	for _, key := range loc.unknown {
+		p := Problem{File: filename, Rule: -1, Message: fmt.Sprintf("unknown key %q", key.path)}
+		if rule, field, ok := splitRulePath(key.path); ok {
+			p.Rule, p.Field = rule, field
+			p.Message = "unknown key"
+		}
+		p.Line, p.Column = lineColumn(data, key.offset)
+		loadErr.add(p)
+	}
+
+	parsed := make([]events.Event, len(raw.Events))
+	defined := make(map[string]*events.Event)
+	for i := range parsed {
+		ev := &parsed[i]
+		errs := decodeRule(raw.Events[i], ev)
+		ev.Origin, ev.Position = filename, i
+		switch {
+		case len(errs) > 0:
+			// Validating what could be decoded would only add noise.
+		case ev.ID != "" && ev.Name == "":
+			target, ok := defined[ev.ID]
+			if !ok && base != nil {
+				target, ok = base.GetEventByID(ev.ID)
+			}
+			if !ok {
+				errs = append(errs, &events.RuleError{Field: "id", Err: fmt.Errorf("no rule with id %q to override", ev.ID)})
+				break
+			}
+			merged := target.Clone()
+			if errs = decodeRule(raw.Events[i], merged); len(errs) > 0 {
+				break
+			}
+			merged.Origin, merged.Position = filename, i
+			*ev = *merged
+			errs = ev.Validate()
+		case ev.ID != "":
+			_, exists := defined[ev.ID]
+			if !exists && base != nil {
+				_, exists = base.GetEventByID(ev.ID)
+			}
+			if exists {
+				errs = append(errs, &events.RuleError{Field: "id", Err: fmt.Errorf("duplicate id %q; leave out name to override that rule", ev.ID)})
+			}
+			errs = append(errs, ev.Validate()...)
+		default:
+			errs = ev.Validate()
+		}
+		if ev.ID != "" {
+			defined[ev.ID] = ev
 		}
-		e := events.Event{
-			Name:     event.Name,
-			Regex:    event.Regex,
-			Command:  event.Command,
-			UseShell: event.UseShell,
+
+		for _, e := range errs {
+			p := Problem{File: filename, Rule: i, Field: e.Field, Message: e.Err.Error()}
+			path := fmt.Sprintf("events[%d]", i)
+			if e.Field != "" {
+				path += "." + e.Field
+			}
+			if offset, ok := loc.locate(path); ok {
+				p.Line, p.Column = lineColumn(data, offset)
+			}
+			loadErr.add(p)
 		}
-		events.DefaultRegistry.RegisterExplicit(&e)
-		fmt.Printf("  Loaded: %s -> %s\n", event.Name, event.Regex)
 	}
 
-	return nil
+	sort.SliceStable(loadErr.Problems, func(i, j int) bool {
+		a, b := loadErr.Problems[i], loadErr.Problems[j]
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+
+	invalid := make(map[int]bool)
+	for _, p := range loadErr.Problems {
+		if p.Rule >= 0 {
+			invalid[p.Rule] = true
+		}
+	}
+	var rules []events.Event
+	for i, ev := range parsed {
+		if !invalid[i] {
+			rules = append(rules, ev)
+		}
+	}
+	return rules, loadErr.orNil()
 }
 
-func LoadEventsFromDirectory(dirPath string) error {
-	fmt.Printf("Scanning directory: %s\n", dirPath)
+// decodeRule decodes one entry of "events" into ev. When that fails, the
+// entry's keys are decoded again one at a time, so that every bad value is
+// reported against its own key.
+func decodeRule(entry json.RawMessage, ev *events.Event) []*events.RuleError {
+	err := json.Unmarshal(entry, ev)
+	if err == nil {
+		return nil
+	}
+	var keys map[string]json.RawMessage
+	if json.Unmarshal(entry, &keys) != nil {
+		return []*events.RuleError{{Err: fmt.Errorf("rule must be a JSON object")}}
+	}
+
+	var errs []*events.RuleError
+	for key, value := range keys {
+		single, _ := json.Marshal(map[string]json.RawMessage{key: value})
+		if err := json.Unmarshal(single, ev); err != nil {
+			errs = append(errs, &events.RuleError{Field: key, Err: decodeError(err)})
+		}
+	}
+	return errs
+}
+
+// decodeError rewords the type errors of encoding/json, which name Go types
+// and struct fields, in terms of the config file.
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("invalid value: expected %s, got %s", typeErr.Type, typeErr.Value)
+	}
+	return fmt.Errorf("invalid value: %w", err)
+}
 
-	var loaded int
-	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+// splitRulePath turns "events[3].class" into (3, "class").
+func splitRulePath(path string) (int, string, bool) {
+	rest, ok := strings.CutPrefix(path, "events[")
+	if !ok {
+		return 0, "", false
+	}
+	index, field, ok := strings.Cut(rest, "]")
+	if !ok {
+		return 0, "", false
+	}
+	rule, err := strconv.Atoi(index)
+	if err != nil {
+		return 0, "", false
+	}
+	return rule, strings.TrimPrefix(field, "."), true
+}
+
+// LoadEventsFromFile validates every rule of filename and registers the
+// valid ones into r. The problems with the others are returned as a
+// *LoadError; it is up to the caller whether to keep the registry anyway.
+func LoadEventsFromFile(r *events.Registry, filename string) error {
+	rules, err := ParseFile(filename, r)
+	if len(rules) == 0 {
+		return err
+	}
+
+	fmt.Printf("Loading %d event(s) from %s\n", len(rules), filename)
+	r.AddSource(filename)
+	for i := range rules {
+		e := &rules[i]
+		r.RegisterExplicit(e)
+		if !e.IsEnabled() {
+			fmt.Printf("  Disabled: %s\n", e.Identity())
+			continue
+		}
+		fmt.Printf("  Loaded: %s -> %s\n", e.Name, e.MatchSummary())
+	}
+	return err
+}
+
+// ListFiles returns the config files LoadEventsFromPath reads from path:
+// the file itself, or every *.json file below a directory.
+func ListFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("path does not exist: %s", path)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if d.IsDir() || !strings.HasSuffix(strings.ToLower(path), ".json") {
-			return nil
-		}
-		if err := LoadEventsFromFile(path); err != nil {
-			fmt.Printf("Failed to load %s: %v\n", path, err)
-		} else {
-			loaded++
+		if !d.IsDir() && strings.HasSuffix(strings.ToLower(p), ".json") {
+			files = append(files, p)
 		}
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("failed to scan directory %s: %w", dirPath, err)
+		return nil, fmt.Errorf("failed to scan directory %s: %w", path, err)
+	}
+	return files, nil
+}
+
+func LoadEventsFromDirectory(r *events.Registry, dirPath string) error {
+	fmt.Printf("Scanning directory: %s\n", dirPath)
+
+	files, err := ListFiles(dirPath)
+	if err != nil {
+		return err
+	}
+
+	before := len(r.Sources())
+	loadErr := &LoadError{}
+	for _, path := range files {
+		loadErr.merge(path, LoadEventsFromFile(r, path))
 	}
 
-	fmt.Printf("%d JSON file(s) loaded\n", loaded)
-	return nil
+	fmt.Printf("%d JSON file(s) loaded\n", len(r.Sources())-before)
+	return loadErr.orNil()
 }
 
-func LoadEventsFromPath(path string) error {
+func LoadEventsFromPath(r *events.Registry, path string) error {
 	info, err := os.Stat(path)
 	if err != nil {
 		return fmt.Errorf("path does not exist: %s", path)
 	}
 	if info.IsDir() {
-		return LoadEventsFromDirectory(path)
+		return LoadEventsFromDirectory(r, path)
 	}
-	return LoadEventsFromFile(path)
+	return LoadEventsFromFile(r, path)
 }
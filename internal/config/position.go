@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// locator maps JSON paths such as "events[2].class" to byte offsets in a
+// config file, and records keys that do not exist in the target Go type.
+type locator struct {
+	data    []byte
+	dec     *json.Decoder
+	offsets map[string]int
+	unknown []unknownKey
+}
+
+type unknownKey struct {
+	path   string
+	offset int
+}
+
+func newLocator(data []byte) *locator {
+	return &locator{
+		data:    data,
+		dec:     json.NewDecoder(bytes.NewReader(data)),
+		offsets: make(map[string]int),
+	}
+}
+
+// scan walks the document, checking object keys against t. It expects
+// syntactically valid JSON.
+func (l *locator) scan(t reflect.Type) error {
+	return l.value("", t)
+}
+
+func (l *locator) value(path string, t reflect.Type) error {
+	tok, err := l.dec.Token()
+	if err != nil {
+		return err
+	}
+	end := int(l.dec.InputOffset())
+	if _, seen := l.offsets[path]; !seen {
+		l.offsets[path] = l.tokenStart(tok, end)
+	}
+
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for l.dec.More() {
+			keyTok, err := l.dec.Token()
+			if err != nil {
+				return err
+			}
+			key := keyTok.(string)
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			keyOffset := l.tokenStart(keyTok, int(l.dec.InputOffset()))
+			l.offsets[keyPath] = keyOffset
+
+			var child reflect.Type
+			if t != nil {
+				switch t.Kind() {
+				case reflect.Struct:
+					field, ok := jsonField(t, key)
+					if !ok {
+						l.unknown = append(l.unknown, unknownKey{path: keyPath, offset: keyOffset})
+					} else {
+						child = field.Type
+					}
+				case reflect.Map:
+					child = t.Elem()
+				}
+			}
+			if err := l.value(keyPath, child); err != nil {
+				return err
+			}
+		}
+		_, err := l.dec.Token()
+		return err
+
+	case json.Delim('['):
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i := 0; l.dec.More(); i++ {
+			if err := l.value(fmt.Sprintf("%s[%d]", path, i), elem); err != nil {
+				return err
+			}
+		}
+		_, err := l.dec.Token()
+		return err
+	}
+	return nil
+}
+
+// tokenStart finds where the token ending at end begins.
+func (l *locator) tokenStart(tok json.Token, end int) int {
+	switch tok.(type) {
+	case json.Delim:
+		return end - 1
+	case string:
+		// Walk back to the opening quote, skipping escaped quotes.
+		for i := end - 2; i >= 0; i-- {
+			if l.data[i] == '"' && (i == 0 || l.data[i-1] != '\\') {
+				return i
+			}
+		}
+	}
+	start := end
+	for start > 0 && !strings.ContainsRune(" \t\r\n:,[{", rune(l.data[start-1])) {
+		start--
+	}
+	return start
+}
+
+// locate returns the offset of path, falling back to its closest parent.
+func (l *locator) locate(path string) (int, bool) {
+	for {
+		if offset, ok := l.offsets[path]; ok {
+			return offset, true
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			return 0, false
+		}
+		path = path[:i]
+	}
+}
+
+// jsonField finds the struct field encoding/json would decode key into,
+// matching case-insensitively like encoding/json does.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// lineColumn converts a byte offset into a 1-based line and column.
+func lineColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCount(before[lineStart:]) + 1
+}
@@ -0,0 +1,223 @@
+//go:build linux
+
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const watchMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// watchedDir is one target of an inotify watch. file restricts it to a
+// single file name when the watched config path is a file rather than a
+// directory. For a config path that does not exist yet, the closest
+// existing parent is watched with missing set to the next element of
+// target, the config path.
+type watchedDir struct {
+	path      string
+	file      string
+	recursive bool
+	missing   string
+	target    string
+}
+
+// Watcher reports changes to configuration files. Changes are debounced so
+// the burst of events from an editor saving through a temp file and a
+// rename results in a single notification.
+type Watcher struct {
+	fd       int
+	file     *os.File
+	debounce time.Duration
+	changes  chan struct{}
+
+	// inotify returns the same descriptor when a directory is watched
+	// twice, e.g. for auto-config and a -c file inside it, so one
+	// descriptor can have several targets.
+	mu    sync.Mutex
+	dirs  map[int32][]watchedDir
+	timer *time.Timer
+}
+
+func NewWatcher(debounce time.Duration) (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init failed: %w", err)
+	}
+
+	// The raw fd is kept for InotifyAddWatch: calling File.Fd would switch
+	// the descriptor back to blocking mode and keep Close from unblocking run.
+	w := &Watcher{
+		fd:       fd,
+		file:     os.NewFile(uintptr(fd), "inotify"),
+		debounce: debounce,
+		changes:  make(chan struct{}, 1),
+		dirs:     make(map[int32][]watchedDir),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Watch adds a config path. A directory is watched for *.json files, and
+// with recursive set its subdirectories too; a file is watched through its
+// parent directory so atomic renames are seen. A path that does not exist
+// yet is watched for from its closest existing parent.
+func (w *Watcher) Watch(path string, recursive bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return w.watchParent(path, recursive)
+	}
+	if info.IsDir() {
+		return w.addDir(path, recursive)
+	}
+	return w.add(watchedDir{path: filepath.Dir(path), file: filepath.Base(path)})
+}
+
+// Changes delivers one value per debounced burst of configuration changes.
+func (w *Watcher) Changes() <-chan struct{} { return w.changes }
+
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+	return w.file.Close()
+}
+
+// watchParent waits for path to appear by watching its closest existing
+// parent directory.
+func (w *Watcher) watchParent(path string, recursive bool) error {
+	path = filepath.Clean(path)
+	child := path
+	for {
+		parent := filepath.Dir(child)
+		if info, err := os.Stat(parent); err == nil {
+			if !info.IsDir() {
+				return nil
+			}
+			return w.add(watchedDir{path: parent, missing: filepath.Base(child), target: path, recursive: recursive})
+		}
+		if parent == child {
+			return nil
+		}
+		child = parent
+	}
+}
+
+func (w *Watcher) addDir(root string, recursive bool) error {
+	if !recursive {
+		return w.add(watchedDir{path: root})
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return w.add(watchedDir{path: path, recursive: true})
+	})
+}
+
+func (w *Watcher) add(dir watchedDir) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir.path, watchMask)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir.path, err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !slices.Contains(w.dirs[int32(wd)], dir) {
+		w.dirs[int32(wd)] = append(w.dirs[int32(wd)], dir)
+	}
+	return nil
+}
+
+func (w *Watcher) remove(wd int32, dir watchedDir) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dirs[wd] = slices.DeleteFunc(w.dirs[wd], func(d watchedDir) bool { return d == dir })
+}
+
+func (w *Watcher) run() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			name := strings.TrimRight(string(nameBytes), "\x00")
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			w.handle(event.Wd, event.Mask, name)
+		}
+	}
+}
+
+func (w *Watcher) handle(wd int32, mask uint32, name string) {
+	w.mu.Lock()
+	dirs := slices.Clone(w.dirs[wd])
+	w.mu.Unlock()
+
+	for _, dir := range dirs {
+		w.handleTarget(wd, dir, mask, name)
+	}
+}
+
+func (w *Watcher) handleTarget(wd int32, dir watchedDir, mask uint32, name string) {
+	if dir.missing != "" {
+		// Watch again from scratch: either the config path now exists or
+		// a parent closer to it does.
+		if name == dir.missing && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+			w.remove(wd, dir)
+			w.Watch(dir.target, dir.recursive)
+			if _, err := os.Stat(dir.target); err == nil {
+				w.changed()
+			}
+		}
+		return
+	}
+
+	if mask&syscall.IN_ISDIR != 0 {
+		if dir.recursive && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+			w.addDir(filepath.Join(dir.path, name), true)
+			w.changed()
+		}
+		return
+	}
+
+	if dir.file != "" {
+		if name == dir.file {
+			w.changed()
+		}
+		return
+	}
+	if strings.HasSuffix(strings.ToLower(name), ".json") {
+		w.changed()
+	}
+}
+
+func (w *Watcher) changed() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.debounce, func() {
+		select {
+		case w.changes <- struct{}{}:
+		default:
+		}
+	})
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Watcher is only implemented on Linux, where inotify is available.
+type Watcher struct{}
+
+func NewWatcher(debounce time.Duration) (*Watcher, error) {
+	return nil, fmt.Errorf("config watching is only supported on Linux")
+}
+
+func (w *Watcher) Watch(path string, recursive bool) error { return nil }
+
+func (w *Watcher) Changes() <-chan struct{} { return nil }
+
+func (w *Watcher) Close() error { return nil }
@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Client sends requests to the running daemon over one connection.
+type Client struct {
+	conn   net.Conn
+	dec    *json.Decoder
+	enc    *json.Encoder
+	nextID int64
+}
+
+func Dial() (*Client, error) {
+	conn, err := net.Dial("unix", socketPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to daemon (is hyprtrigger running?): %w", err)
+	}
+	return &Client{conn: conn, dec: json.NewDecoder(conn), enc: json.NewEncoder(conn)}, nil
+}
+
+// Call invokes method with params and decodes the result into result,
+// which may be nil. A failed request returns an *Error.
+func (c *Client) Call(method string, params, result any) error {
+	c.nextID++
+	req := Request{Version: ProtocolVersion, ID: c.nextID, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode params: %w", err)
+		}
+		req.Params = data
+	}
+	if err := c.enc.Encode(req); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp Response
+	if err := c.dec.Decode(&resp); err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	switch {
+	case resp.ID != req.ID:
+		return fmt.Errorf("response id %d does not match request id %d", resp.ID, req.ID)
+	case resp.Error != nil:
+		return resp.Error
+	case result != nil && len(resp.Result) > 0:
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode result: %w", err)
+		}
+	}
+	return nil
+}
+
+// Stream invokes a streaming method, then passes the method and params of
+// each notification to handle until the daemon closes the connection or
+// handle returns an error, which Stream returns.
+func (c *Client) Stream(method string, params any, handle func(method string, params json.RawMessage) error) error {
+	if err := c.Call(method, params, nil); err != nil {
+		return err
+	}
+	for {
+		var n struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := c.dec.Decode(&n); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read notification: %w", err)
+		}
+		if err := handle(n.Method, n.Params); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Call dials the daemon, makes a single call and closes the connection.
+func Call(method string, params, result any) error {
+	c, err := Dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Call(method, params, result)
+}
@@ -1,23 +1,26 @@
 package daemon
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 type Daemon struct {
 	listener     net.Listener
 	socketPath   string
-	reloadChan   chan bool
+	reloadChan   chan chan error
+	applyChan    chan bool
 	shutdownChan chan bool
 	stopped      bool
-}
 
-type Command struct {
-	Type string `json:"type"`
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	streams  map[string]StreamHandler
 }
 
 func socketPath() string {
@@ -29,11 +32,37 @@ func socketPath() string {
 }
 
 func NewDaemon() *Daemon {
-	return &Daemon{
+	d := &Daemon{
 		socketPath:   socketPath(),
-		reloadChan:   make(chan bool, 1),
+		reloadChan:   make(chan chan error, 1),
+		applyChan:    make(chan bool, 1),
 		shutdownChan: make(chan bool, 1),
+		handlers:     make(map[string]Handler),
+		streams:      make(map[string]StreamHandler),
 	}
+	d.Handle("reload", d.handleReload)
+	d.Handle("apply", d.handleApply)
+	d.Handle("shutdown", d.handleShutdown)
+	return d
+}
+
+// Handle registers the handler for a method, replacing any previous one.
+// Handlers run on the connection's goroutine.
+func (d *Daemon) Handle(method string, h Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[method] = h
+	delete(d.streams, method)
+}
+
+// HandleStream registers a streaming method, replacing any previous
+// handler. Once it has been answered, the connection carries only the
+// stream's notifications until either side closes it.
+func (d *Daemon) HandleStream(method string, h StreamHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.streams[method] = h
+	delete(d.handlers, method)
 }
 
 func (d *Daemon) Start() error {
@@ -66,34 +95,141 @@ func (d *Daemon) handleConnections() {
 	}
 }
 
+const maxRequestSize = 1 << 20
+
+// handleConnection answers requests, one per line, until the client closes
+// the connection or a stream is started.
 func (d *Daemon) handleConnection(conn net.Conn) {
 	defer conn.Close()
 
-	var cmd Command
-	if err := json.NewDecoder(conn).Decode(&cmd); err != nil {
-		return
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxRequestSize)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		resp, stream := d.serve(scanner.Bytes())
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+		if stream != nil {
+			d.runStream(scanner, enc, stream)
+			return
+		}
 	}
+}
 
-	switch cmd.Type {
-	case "reload":
-		select {
-		case d.reloadChan <- true:
-			conn.Write([]byte("OK: Reload initiated\n"))
-		default:
-			conn.Write([]byte("OK: Reload already in progress\n"))
+// runStream runs stream until it returns or the client goes away. Anything
+// the client sends meanwhile is discarded.
+func (d *Daemon) runStream(scanner *bufio.Scanner, enc *json.Encoder, stream StreamFunc) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for scanner.Scan() {
+		}
+	}()
+	stream(func(method string, params any) error {
+		return enc.Encode(Notification{Version: ProtocolVersion, Method: method, Params: params})
+	}, done)
+}
+
+// serve answers one request. For a streaming method whose handler
+// succeeded it also returns the stream to run.
+func (d *Daemon) serve(line []byte) (Response, StreamFunc) {
+	resp := Response{Version: ProtocolVersion}
+
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		resp.Error = NewError(CodeParseError, "invalid request: %v", err)
+		return resp, nil
+	}
+	resp.ID = req.ID
+
+	switch {
+	case req.Version != ProtocolVersion:
+		resp.Error = NewError(CodeUnsupportedVersion, "unsupported protocol version %d, want %d", req.Version, ProtocolVersion)
+		return resp, nil
+	case req.Method == "":
+		resp.Error = NewError(CodeInvalidRequest, "missing method")
+		return resp, nil
+	}
+
+	d.mu.RLock()
+	h, ok := d.handlers[req.Method]
+	sh, isStream := d.streams[req.Method]
+	d.mu.RUnlock()
+
+	var (
+		result any
+		stream StreamFunc
+		err    error
+	)
+	switch {
+	case ok:
+		result, err = h(req.Params)
+	case isStream:
+		stream, err = sh(req.Params)
+		result = struct{}{}
+	default:
+		resp.Error = NewError(CodeMethodNotFound, "unknown method %q", req.Method)
+		return resp, nil
+	}
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = NewError(CodeInternal, "%v", err)
+		}
+		resp.Error = rpcErr
+		return resp, nil
+	}
+	if result != nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = NewError(CodeInternal, "failed to encode result: %v", err)
+			return resp, nil
 		}
-	case "status":
-		conn.Write([]byte("OK: Daemon is running\n"))
-	case "shutdown":
-		conn.Write([]byte("OK: Shutting down\n"))
-		d.shutdownChan <- true
+		resp.Result = data
+	}
+	return resp, stream
+}
+
+func (d *Daemon) handleReload(json.RawMessage) (any, error) {
+	reply := make(chan error, 1)
+	select {
+	case d.reloadChan <- reply:
 	default:
-		conn.Write([]byte("ERROR: Unknown command\n"))
+		return nil, NewError(CodeBusy, "reload already in progress")
+	}
+	if err := <-reply; err != nil {
+		return nil, err
 	}
+	return struct{}{}, nil
 }
 
-func (d *Daemon) GetReloadChannel() <-chan bool   { return d.reloadChan }
-func (d *Daemon) GetShutdownChannel() <-chan bool { return d.shutdownChan }
+func (d *Daemon) handleApply(json.RawMessage) (any, error) {
+	select {
+	case d.applyChan <- true:
+		return ApplyResult{Started: true}, nil
+	default:
+		return ApplyResult{Started: false}, nil
+	}
+}
+
+func (d *Daemon) handleShutdown(json.RawMessage) (any, error) {
+	select {
+	case d.shutdownChan <- true:
+	default:
+	}
+	return struct{}{}, nil
+}
+
+// GetReloadChannel delivers reload requests. The receiver must send the
+// outcome on the given channel; it is reported back to the client, as is
+// when it is an *Error.
+func (d *Daemon) GetReloadChannel() <-chan chan error { return d.reloadChan }
+func (d *Daemon) GetApplyChannel() <-chan bool        { return d.applyChan }
+func (d *Daemon) GetShutdownChannel() <-chan bool     { return d.shutdownChan }
 
 func (d *Daemon) Stop() {
 	if d.stopped {
@@ -114,27 +250,3 @@ func IsDaemonRunning() bool {
 	conn.Close()
 	return true
 }
-
-func SendCommand(cmdType string) error {
-	conn, err := net.Dial("unix", socketPath())
-	if err != nil {
-		return fmt.Errorf("failed to connect to daemon (is hyprtrigger running?): %w", err)
-	}
-	defer conn.Close()
-
-	if err := json.NewEncoder(conn).Encode(Command{Type: cmdType}); err != nil {
-		return fmt.Errorf("failed to send command: %w", err)
-	}
-
-	buf := make([]byte, 1024)
-	n, err := conn.Read(buf)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-	fmt.Print(string(buf[:n]))
-	return nil
-}
-
-func SendReload() error   { return SendCommand("reload") }
-func SendStatus() error   { return SendCommand("status") }
-func SendShutdown() error { return SendCommand("shutdown") }
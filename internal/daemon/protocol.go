@@ -0,0 +1,205 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"hyprtrigger/internal/events"
+)
+
+// ProtocolVersion is the version of the control socket protocol. Requests
+// carrying another version are rejected.
+//
+// The protocol is newline-delimited JSON: a client writes one Request per
+// line and reads one Response per line, in order, on the same connection
+// for as long as it keeps it open. After answering a streaming method such
+// as subscribe, the daemon only writes Notifications on that connection.
+const ProtocolVersion = 1
+
+type Request struct {
+	Version int             `json:"version"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type Response struct {
+	Version int             `json:"version"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Notification is a message of a stream. It has no id and gets no reply.
+type Notification struct {
+	Version int    `json:"version"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// Error codes. The negative ones follow JSON-RPC 2.0.
+const (
+	CodeParseError         = -32700
+	CodeInvalidRequest     = -32600
+	CodeMethodNotFound     = -32601
+	CodeInvalidParams      = -32602
+	CodeInternal           = -32603
+	CodeUnsupportedVersion = 1
+	CodeBusy               = 2
+	CodeReloadFailed       = 3
+)
+
+// Error is a failed request. Data optionally carries details, such as the
+// configuration problems of a failed reload.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func NewError(code int, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Handler serves one method. Returning an *Error sets the error code; any
+// other error is reported as CodeInternal.
+type Handler func(params json.RawMessage) (any, error)
+
+// StreamFunc sends notifications until done is closed, which happens when
+// the client disconnects, or until send fails.
+type StreamFunc func(send func(method string, params any) error, done <-chan struct{})
+
+// StreamHandler validates the params of a streaming method and returns the
+// stream to run once the request has been answered.
+type StreamHandler func(params json.RawMessage) (StreamFunc, error)
+
+// DecodeParams unmarshals params into v, reporting CodeInvalidParams on
+// failure. Missing params leave v untouched.
+func DecodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return NewError(CodeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
+
+// Typed params and results of the built-in methods.
+
+type ApplyResult struct {
+	Started bool `json:"started"`
+}
+
+type StatusResult struct {
+	PID       int             `json:"pid"`
+	Version   string          `json:"version"`
+	StartedAt time.Time       `json:"started_at"`
+	Uptime    events.Duration `json:"uptime"`
+	Config    ConfigStatus    `json:"config"`
+	Hyprland  HyprlandStatus  `json:"hyprland"`
+	Rules     []RuleCount     `json:"rules"`
+	Stats     events.Stats    `json:"stats"`
+	Paused    bool            `json:"paused"`
+	// PausedUntil is zero when paused until resumed.
+	PausedUntil time.Time `json:"paused_until,omitzero"`
+}
+
+type ConfigStatus struct {
+	Builtins bool      `json:"builtins"`
+	Sources  []string  `json:"sources"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+type HyprlandStatus struct {
+	Instance  string    `json:"instance,omitempty"`
+	Connected bool      `json:"connected"`
+	Since     time.Time `json:"since"`
+}
+
+// RuleCount counts the active rules for one event. Disabled rules are
+// counted separately, not in Builtin or User.
+type RuleCount struct {
+	Event    string `json:"event"`
+	Builtin  int    `json:"builtin"`
+	User     int    `json:"user"`
+	Disabled int    `json:"disabled,omitempty"`
+}
+
+type HistoryParams struct {
+	Rule   string `json:"rule,omitempty"`
+	Failed bool   `json:"failed,omitempty"`
+}
+
+type HistoryResult struct {
+	Executions []events.Execution `json:"executions"`
+}
+
+type RulesParams struct {
+	// Event keeps the rules of one event. Origin keeps the rules of a
+	// config file, given as its path, base name or a parent directory, or
+	// "builtin".
+	Event  string `json:"event,omitempty"`
+	Origin string `json:"origin,omitempty"`
+}
+
+type RulesResult struct {
+	Rules []RuleInfo `json:"rules"`
+}
+
+// RuleInfo describes one rule of the live rule set, in evaluation order.
+type RuleInfo struct {
+	ID       string `json:"id,omitempty"`
+	Rule     string `json:"rule"`
+	Event    string `json:"event"`
+	Origin   string `json:"origin"`
+	Position int    `json:"position"`
+	Enabled  bool   `json:"enabled"`
+	Priority int    `json:"priority,omitempty"`
+	Final    bool   `json:"final,omitempty"`
+	Match    string `json:"match"`
+	Action   string `json:"action"`
+	// Disabled is set by the rule.disable method, as opposed to Enabled
+	// which comes from the configuration.
+	Disabled      bool      `json:"disabled,omitempty"`
+	DisabledUntil time.Time `json:"disabled_until,omitzero"`
+	events.RuleStats
+}
+
+// RuleControlParams select a rule by id (or file#index) for rule.disable
+// and rule.enable. For limits rule.disable; zero disables until
+// rule.enable.
+type RuleControlParams struct {
+	Rule string          `json:"rule"`
+	For  events.Duration `json:"for,omitempty"`
+}
+
+// PauseParams limit pause; zero pauses until resume.
+type PauseParams struct {
+	For events.Duration `json:"for,omitempty"`
+}
+
+// ControlResult reports whether a control method changed anything and,
+// for disable and pause, when it expires (zero for never).
+type ControlResult struct {
+	Changed bool      `json:"changed"`
+	Until   time.Time `json:"until,omitzero"`
+}
+
+// SubscribeParams filter the subscribe stream. Event keeps one event name;
+// Match is a regex tested against the raw socket2 line.
+type SubscribeParams struct {
+	Event string `json:"event,omitempty"`
+	Match string `json:"match,omitempty"`
+}
+
+// WatchEvent is the params of each "event" notification of subscribe.
+// Dropped counts the events passing the filter that were skipped since the
+// previous one because the client did not keep up.
+type WatchEvent struct {
+	events.Trace
+	Dropped uint64 `json:"dropped,omitempty"`
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// controls are runtime switches set over the control socket. They live in
+// the Processor, keyed by stateKey, so they survive reloads and stay with
+// their rule when rules are added above it.
+type controls struct {
+	mu          sync.Mutex
+	muted       map[string]time.Time // rule -> expiry, zero for never
+	paused      bool
+	pausedUntil time.Time
+}
+
+// DisableRule stops a rule from firing, for d or, if d is zero, until
+// EnableRule. Matches are still counted as skipped.
+func (p *Processor) DisableRule(ev *Event, d time.Duration) time.Time {
+	c := &p.controls
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.muted == nil {
+		c.muted = make(map[string]time.Time)
+	}
+	var until time.Time
+	if d > 0 {
+		until = time.Now().Add(d)
+	}
+	c.muted[ev.stateKey()] = until
+	return until
+}
+
+// EnableRule lifts DisableRule. It reports whether the rule was disabled.
+func (p *Processor) EnableRule(ev *Event) bool {
+	c := &p.controls
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.muted[ev.stateKey()]
+	delete(c.muted, ev.stateKey())
+	return ok
+}
+
+// RuleDisabled reports whether the rule is disabled at runtime, and until
+// when (zero for no expiry).
+func (p *Processor) RuleDisabled(ev *Event) (bool, time.Time) {
+	c := &p.controls
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	until, ok := c.muted[ev.stateKey()]
+	if ok && !until.IsZero() && time.Now().After(until) {
+		delete(c.muted, ev.stateKey())
+		return false, time.Time{}
+	}
+	return ok, until
+}
+
+// Pause stops every rule from firing, for d or, if d is zero, until Resume.
+func (p *Processor) Pause(d time.Duration) time.Time {
+	c := &p.controls
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = true
+	c.pausedUntil = time.Time{}
+	if d > 0 {
+		c.pausedUntil = time.Now().Add(d)
+	}
+	return c.pausedUntil
+}
+
+// Resume lifts Pause. It reports whether the processor was paused.
+func (p *Processor) Resume() bool {
+	c := &p.controls
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	was := c.paused
+	c.paused = false
+	c.pausedUntil = time.Time{}
+	return was
+}
+
+// Paused reports whether the processor is paused, and until when (zero
+// for no expiry).
+func (p *Processor) Paused() (bool, time.Time) {
+	c := &p.controls
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused && !c.pausedUntil.IsZero() && time.Now().After(c.pausedUntil) {
+		c.paused = false
+		c.pausedUntil = time.Time{}
+	}
+	return c.paused, c.pausedUntil
+}
+
+// skipped reports whether a matching rule must not fire because it, or the
+// whole processor, is switched off.
+func (p *Processor) skipped(ev *Event) bool {
+	if paused, _ := p.Paused(); paused {
+		return true
+	}
+	disabled, _ := p.RuleDisabled(ev)
+	return disabled
+}
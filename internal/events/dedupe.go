@@ -0,0 +1,196 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Dedupe modes.
+const (
+	DedupeNone           = "none"
+	DedupeWindow         = "window"
+	DedupeOncePerWindow  = "once_per_window"
+	DedupeOncePerSession = "once_per_session"
+)
+
+// DefaultDedupeWindow applies to rules without a dedupe block.
+const DefaultDedupeWindow = 2 * time.Second
+
+// Dedupe decides when a rule that already fired is suppressed:
+//
+//   - none: never
+//   - window: for Duration after firing for the same window
+//   - once_per_window: until the window closes
+//   - once_per_session: until the daemon exits, whatever the window
+//
+// In JSON a string is shorthand for the mode, with "window(10s)" setting the
+// duration as well.
+type Dedupe struct {
+	Mode     string   `json:"mode"`
+	Duration Duration `json:"duration,omitempty"`
+}
+
+func (d *Dedupe) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*d = Dedupe{Mode: s}
+		if rest, ok := strings.CutPrefix(s, DedupeWindow+"("); ok && strings.HasSuffix(rest, ")") {
+			duration, err := time.ParseDuration(strings.TrimSuffix(rest, ")"))
+			if err != nil {
+				return fmt.Errorf("invalid dedupe %q: %w", s, err)
+			}
+			*d = Dedupe{Mode: DedupeWindow, Duration: Duration(duration)}
+		}
+		return nil
+	}
+
+	type plain Dedupe
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*d = Dedupe(p)
+	return nil
+}
+
+func (d *Dedupe) validate() error {
+	switch d.Mode {
+	case DedupeNone, DedupeOncePerWindow, DedupeOncePerSession:
+		if d.Duration != 0 {
+			return fmt.Errorf("duration only applies to mode %q", DedupeWindow)
+		}
+	case DedupeWindow:
+		if d.Duration < 0 {
+			return fmt.Errorf("negative duration")
+		}
+	default:
+		return fmt.Errorf("unknown mode %q (want none, window, once_per_window or once_per_session)", d.Mode)
+	}
+	return nil
+}
+
+func (ev *Event) dedupe() Dedupe {
+	if ev.Dedupe == nil {
+		return Dedupe{Mode: DedupeWindow, Duration: Duration(DefaultDedupeWindow)}
+	}
+	d := *ev.Dedupe
+	if d.Mode == DedupeWindow && d.Duration == 0 {
+		d.Duration = Duration(DefaultDedupeWindow)
+	}
+	return d
+}
+
+// dedupeKey keys the dedupe, debounce and throttle state of a rule, by
+// stateKey, for a window.
+type dedupeKey struct {
+	rule   string
+	window string
+}
+
+// stateKey names a rule in dedupeKey: its ID, or else its file and its
+// configuration. Unlike its position, that does not pass to another rule
+// when rules are added or removed above it and the file is reloaded.
+func (ev *Event) stateKey() string {
+	if ev.ID != "" {
+		return ev.ID
+	}
+	return ev.Origin + "@" + ev.Fingerprint()
+}
+
+// deduplicationManager remembers which rule fired for which window. Entries
+// of timed rules expire; once_per_window entries are dropped when their
+// window closes. Rules are keyed by identity, so state survives a reload.
+type deduplicationManager struct {
+	entries   map[dedupeKey]time.Time // expiry, zero for never
+	byWindow  map[string][]dedupeKey
+	lastSweep time.Time
+}
+
+const dedupeSweepInterval = time.Minute
+
+func newDeduplicationManager() *deduplicationManager {
+	return &deduplicationManager{
+		entries:  make(map[dedupeKey]time.Time),
+		byWindow: make(map[string][]dedupeKey),
+	}
+}
+
+func (dm *deduplicationManager) key(ev *Event, windowID string) (dedupeKey, bool) {
+	switch ev.dedupe().Mode {
+	case DedupeNone:
+		return dedupeKey{}, false
+	case DedupeOncePerSession:
+		return dedupeKey{rule: ev.stateKey()}, true
+	default:
+		return dedupeKey{rule: ev.stateKey(), window: windowID}, true
+	}
+}
+
+func (dm *deduplicationManager) suppressed(ev *Event, windowID string) bool {
+	key, ok := dm.key(ev, windowID)
+	if !ok {
+		return false
+	}
+	expiry, found := dm.entries[key]
+	if !found {
+		return false
+	}
+	if !expiry.IsZero() && time.Now().After(expiry) {
+		delete(dm.entries, key)
+		return false
+	}
+	return true
+}
+
+func (dm *deduplicationManager) record(ev *Event, windowID string) {
+	key, ok := dm.key(ev, windowID)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	var expiry time.Time
+	if d := ev.dedupe(); d.Mode == DedupeWindow {
+		expiry = now.Add(time.Duration(d.Duration))
+	}
+	if _, found := dm.entries[key]; !found && key.window != "" {
+		dm.byWindow[key.window] = append(dm.byWindow[key.window], key)
+	}
+	dm.entries[key] = expiry
+
+	if now.Sub(dm.lastSweep) > dedupeSweepInterval {
+		dm.sweep(now)
+	}
+}
+
+// forgetWindow drops every entry recorded for a closed window.
+func (dm *deduplicationManager) forgetWindow(windowID string) {
+	for _, key := range dm.byWindow[windowID] {
+		delete(dm.entries, key)
+	}
+	delete(dm.byWindow, windowID)
+}
+
+func (dm *deduplicationManager) sweep(now time.Time) {
+	dm.lastSweep = now
+	for key, expiry := range dm.entries {
+		if !expiry.IsZero() && now.After(expiry) {
+			delete(dm.entries, key)
+		}
+	}
+	for window, keys := range dm.byWindow {
+		live := keys[:0]
+		for _, key := range keys {
+			if _, found := dm.entries[key]; found {
+				live = append(live, key)
+			}
+		}
+		if len(live) == 0 {
+			delete(dm.byWindow, window)
+		} else {
+			dm.byWindow[window] = live
+		}
+	}
+}
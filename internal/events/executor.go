@@ -1,37 +1,242 @@
 package events
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"regexp"
 	"strings"
+	"syscall"
+	"time"
 )
 
-func (ev *Event) ExecuteCommand(windowID string) error {
-	command := strings.ReplaceAll(ev.Command, "{WINDOW_ID}", windowID)
+// ExecuteCommand runs the rule's command or argv with event placeholders and
+// the named regex captures substituted. The command runs in its own process
+// group, which is killed as a whole once the rule's timeout expires. Its
+// output and exit status are recorded in rec.
+func (ev *Event) ExecuteCommand(data *EventData, captures map[string]string, rec *Execution) error {
+	vars := placeholderValues(data, captures)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ev.timeout())
+	defer cancel()
 
-	fmt.Printf("Execution de la commande : %s\n", ev.Command)
 	var cmd *exec.Cmd
-	if ev.UseShell {
-		cmd = exec.Command("sh", "-c", command)
+	if ev.UseShell && len(ev.Argv) == 0 {
+		script, env := shellPlaceholders(ev.Command, vars)
+		rec.Command = script
+		cmd = exec.CommandContext(ctx, "sh", "-c", script)
+		cmd.Env = append(os.Environ(), env...)
 	} else {
-		parts := strings.Fields(command)
-		if len(parts) == 0 {
-			return fmt.Errorf("empty command")
+		args, err := ev.args()
+		if err != nil {
+			return err
 		}
-		cmd = exec.Command(parts[0], parts[1:]...)
+		// Expand each word on its own so a value containing spaces or
+		// quotes stays one argument.
+		for i, arg := range args {
+			args[i] = expandPlaceholders(arg, vars)
+		}
+		rec.Command = quoteArgs(args)
+		cmd = exec.CommandContext(ctx, args[0], args[1:]...)
+	}
+	fmt.Printf("Running rule %s: %s\n", ev.Identity(), rec.Command)
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+
+	stdout, err := newOutputCapture()
+	if err != nil {
+		return err
+	}
+	stderr, err := newOutputCapture()
+	if err != nil {
+		stdout.abort()
+		return err
 	}
+	cmd.Stdout = stdout.w
+	cmd.Stderr = stderr.w
 
-	return cmd.Run()
+	err = cmd.Start()
+	stdout.started()
+	stderr.started()
+	if err == nil {
+		err = cmd.Wait()
+	}
+	deadline := time.Now().Add(outputGrace)
+	rec.Stdout = stdout.output(deadline)
+	rec.Stderr = stderr.output(deadline)
+	if err == nil {
+		return nil
+	}
+	execErr := &ExecError{ExitCode: -1, Stderr: rec.Stderr, Err: err}
+	var exitErr *exec.ExitError
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		execErr.Err = fmt.Errorf("timed out after %v", ev.timeout())
+	} else if errors.As(err, &exitErr) {
+		execErr.ExitCode = exitErr.ExitCode()
+	}
+	return execErr
 }
 
-func (ev *Event) Match(input string) bool {
-	if ev.compiled == nil {
+// args returns the unexpanded argument list: argv as given, or command
+// split into words.
+func (ev *Event) args() ([]string, error) {
+	args := ev.Argv
+	if len(args) == 0 {
 		var err error
-		ev.compiled, err = regexp.Compile(ev.Regex)
+		if args, err = splitWords(ev.Command); err != nil {
+			return nil, fmt.Errorf("invalid command: %w", err)
+		}
+	} else {
+		args = append([]string(nil), args...)
+	}
+	if len(args) == 0 || args[0] == "" {
+		return nil, fmt.Errorf("empty command")
+	}
+	return args, nil
+}
+
+// quoteArgs renders an argument list the way a shell would need it typed.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func (ev *Event) timeout() time.Duration {
+	if ev.Timeout > 0 {
+		return time.Duration(ev.Timeout)
+	}
+	return DefaultTimeout
+}
+
+// Compile prepares the rule's regexes. Registries compile every rule they
+// receive, so matching never writes to a shared rule; a rule whose regex
+// does not compile never matches.
+func (ev *Event) Compile() error {
+	ev.fingerprint = ""
+	ev.compiled = nil
+	if ev.Regex != "" {
+		re, err := regexp.Compile(ev.Regex)
 		if err != nil {
-			return false
+			return fmt.Errorf("invalid regex %q: %w", ev.Regex, err)
+		}
+		ev.compiled = re
+	}
+	for _, ref := range ev.fieldMatchers() {
+		if err := ref.matcher.compile(); err != nil {
+			return fmt.Errorf("invalid %s regex %q: %w", ref.field, ref.matcher.Regex, err)
+		}
+	}
+	return nil
+}
+
+// Fingerprint identifies the rule's configuration: two rules with the same
+// settings have the same fingerprint, wherever they were loaded from.
+func (ev *Event) Fingerprint() string {
+	if ev.fingerprint == "" {
+		data, _ := json.Marshal(ev)
+		sum := sha256.Sum256(data)
+		ev.fingerprint = hex.EncodeToString(sum[:8])
+	}
+	return ev.fingerprint
+}
+
+// ExecuteDispatch sends the rule's dispatch list through d in one request.
+// The expanded commands are recorded in rec.
+func (ev *Event) ExecuteDispatch(d Dispatcher, data *EventData, captures map[string]string, rec *Execution) error {
+	vars := placeholderValues(data, captures)
+	commands := make([]string, len(ev.Dispatch))
+	for i, command := range ev.Dispatch {
+		commands[i] = expandPlaceholders(command, vars)
+	}
+	rec.Command = strings.Join(commands, "; ")
+
+	if d == nil {
+		return fmt.Errorf("no Hyprland dispatcher available")
+	}
+	fmt.Printf("Dispatching rule %s: %s\n", ev.Identity(), rec.Command)
+	return d.Dispatch(commands...)
+}
+
+// Identity names the rule in logs: its ID, or else its origin and
+// position.
+func (ev *Event) Identity() string {
+	if ev.ID != "" {
+		return ev.ID
+	}
+	return fmt.Sprintf("%s#%d", ev.Origin, ev.Position)
+}
+
+// IsEnabled reports whether the rule runs; rules are enabled unless they
+// say "enabled": false.
+func (ev *Event) IsEnabled() bool {
+	return ev.Enabled == nil || *ev.Enabled
+}
+
+// HasAction reports whether the rule has a command, an argv or a dispatch
+// list.
+func (ev *Event) HasAction() bool {
+	return ev.HasCommand() || len(ev.Dispatch) > 0
+}
+
+// HasCommand reports whether the rule runs a process.
+func (ev *Event) HasCommand() bool {
+	return ev.Command != "" || len(ev.Argv) > 0
+}
+
+func placeholderValues(data *EventData, captures map[string]string) map[string]string {
+	vars := data.Placeholders()
+	for name, value := range captures {
+		vars[name] = value
+	}
+	return vars
+}
+
+// Match reports whether data satisfies the rule: the legacy regex against
+// Content, and every field matcher against its field.
+func (ev *Event) Match(data *EventData) bool {
+	_, ok := ev.MatchCaptures(data)
+	return ok
+}
+
+// MatchCaptures is Match that also returns the named capture groups of every
+// regex involved, for use as command placeholders.
+func (ev *Event) MatchCaptures(data *EventData) (map[string]string, bool) {
+	captures := make(map[string]string)
+
+	if ev.Regex != "" {
+		if ev.compiled == nil {
+			return nil, false
+		}
+		m := ev.compiled.FindStringSubmatch(data.Content)
+		if m == nil {
+			return nil, false
+		}
+		addCaptures(captures, ev.compiled, m)
+	}
+
+	for _, ref := range ev.fieldMatchers() {
+		if !ref.matcher.matchCaptures(matchedValue(data, ref.field), captures) {
+			return nil, false
+		}
+	}
+	return captures, true
+}
+
+func addCaptures(captures map[string]string, re *regexp.Regexp, m []string) {
+	for i, name := range re.SubexpNames() {
+		if name != "" && m[i] != "" {
+			captures[name] = m[i]
 		}
 	}
-	return ev.compiled.MatchString(input)
 }
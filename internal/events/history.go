@@ -0,0 +1,223 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxHistory = 200
+	maxOutput  = 4096
+)
+
+// Execution records one run of a rule action.
+type Execution struct {
+	Rule     string        `json:"rule"`
+	Event    string        `json:"event"`
+	WindowID string        `json:"window_id,omitempty"`
+	Action   string        `json:"action"`
+	Command  string        `json:"command"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	ExitCode int           `json:"exit_code"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Failed reports whether the action did not succeed.
+func (e Execution) Failed() bool {
+	return e.Error != ""
+}
+
+func newExecution(ev *Event, data *EventData, action string) *Execution {
+	return &Execution{
+		Rule:     ev.Identity(),
+		Event:    data.Name,
+		WindowID: data.WindowID,
+		Action:   action,
+		Start:    time.Now(),
+	}
+}
+
+// ExecError is returned by ExecuteCommand when the command ran but failed.
+// ExitCode is -1 when the process was killed or never started.
+type ExecError struct {
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func (e *ExecError) Error() string { return e.Err.Error() }
+func (e *ExecError) Unwrap() error { return e.Err }
+
+// finish fills in the outcome. Exit code -1 means the action did not run to
+// completion (killed, not started, or a dispatch that Hyprland refused).
+func (e *Execution) finish(err error) {
+	e.Duration = time.Since(e.Start)
+	if err == nil {
+		return
+	}
+	e.Error = err.Error()
+	e.ExitCode = -1
+	var execErr *ExecError
+	if errors.As(err, &execErr) {
+		e.ExitCode = execErr.ExitCode
+	}
+}
+
+// History keeps the most recent executions in memory and optionally appends
+// every one of them to a JSONL file.
+type History struct {
+	mu      sync.Mutex
+	entries []Execution
+	file    *os.File
+}
+
+// SetFile appends future executions to path, creating its directory. An
+// empty path stops writing to a file.
+func (h *History) SetFile(path string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.file != nil {
+		h.file.Close()
+		h.file = nil
+	}
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	h.file = f
+	return nil
+}
+
+func (h *History) add(e Execution) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = append(h.entries, e)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	if h.file != nil {
+		line, _ := json.Marshal(e)
+		if _, err := h.file.Write(append(line, '\n')); err != nil {
+			fmt.Printf("Failed to write history file: %v\n", err)
+		}
+	}
+}
+
+// List returns the recorded executions, oldest first. An empty rule matches
+// every rule; failed restricts the list to failed executions.
+func (h *History) List(rule string, failed bool) []Execution {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var list []Execution
+	for _, e := range h.entries {
+		if rule != "" && e.Rule != rule && !strings.HasPrefix(e.Rule, rule+"#") {
+			continue
+		}
+		if failed && !e.Failed() {
+			continue
+		}
+		list = append(list, e)
+	}
+	return list
+}
+
+// outputGrace is how long a finished command's output is still read, for
+// when a process it started in the background keeps the pipe open.
+const outputGrace = 100 * time.Millisecond
+
+// outputCapture records the tail of a command's stdout or stderr. The pipe
+// is handed to the command as a file, so waiting for the command never
+// waits for processes it left running in the background; those keep a
+// working pipe, drained until they close it, but their output is not
+// recorded.
+type outputCapture struct {
+	w    *os.File
+	done chan struct{}
+
+	mu       sync.Mutex
+	tail     tailBuffer
+	finished bool
+}
+
+func newOutputCapture() (*outputCapture, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output pipe: %w", err)
+	}
+	c := &outputCapture{w: w, done: make(chan struct{}), tail: tailBuffer{limit: maxOutput}}
+	go func() {
+		defer close(c.done)
+		defer r.Close()
+		io.Copy(c, r)
+	}()
+	return c, nil
+}
+
+func (c *outputCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.finished {
+		c.tail.Write(p)
+	}
+	return len(p), nil
+}
+
+// started closes the write end once the command has it.
+func (c *outputCapture) started() {
+	c.w.Close()
+}
+
+func (c *outputCapture) abort() {
+	c.w.Close()
+	<-c.done
+}
+
+// output returns the output once the command has exited, and stops
+// recording. Output still unread at deadline is left out.
+func (c *outputCapture) output(deadline time.Time) string {
+	select {
+	case <-c.done:
+	case <-time.After(time.Until(deadline)):
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.finished = true
+	return c.tail.String()
+}
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return strings.TrimSpace(string(b.buf))
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// FieldMatcher tests a single event field. Every non-empty criterion must
+// hold; Not inverts the result. In JSON a bare string is shorthand for
+// {"regex": "..."}.
+type FieldMatcher struct {
+	Regex    string `json:"regex,omitempty"`
+	Exact    string `json:"exact,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	Not      bool   `json:"not,omitempty"`
+	compiled *regexp.Regexp
+}
+
+func (m *FieldMatcher) UnmarshalJSON(data []byte) error {
+	var regex string
+	if err := json.Unmarshal(data, &regex); err == nil {
+		*m = FieldMatcher{Regex: regex}
+		return nil
+	}
+
+	type plain FieldMatcher
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*m = FieldMatcher(p)
+	return nil
+}
+
+func (m *FieldMatcher) compile() error {
+	m.compiled = nil
+	if m.Regex == "" {
+		return nil
+	}
+	re, err := regexp.Compile(m.Regex)
+	if err != nil {
+		return err
+	}
+	m.compiled = re
+	return nil
+}
+
+func (m *FieldMatcher) Match(value string) bool {
+	return m.matchCaptures(value, nil)
+}
+
+// matchCaptures adds the regex's named groups to captures on a positive
+// match. Negated matchers never contribute captures.
+func (m *FieldMatcher) matchCaptures(value string, captures map[string]string) bool {
+	groups, ok := m.match(value)
+	if m.Not {
+		return !ok
+	}
+	if ok && captures != nil {
+		for name, v := range groups {
+			captures[name] = v
+		}
+	}
+	return ok
+}
+
+func (m *FieldMatcher) match(value string) (map[string]string, bool) {
+	if m.Exact != "" && value != m.Exact {
+		return nil, false
+	}
+	if m.Prefix != "" && !strings.HasPrefix(value, m.Prefix) {
+		return nil, false
+	}
+	if m.Regex == "" {
+		return nil, true
+	}
+
+	if m.compiled == nil {
+		return nil, false
+	}
+	sub := m.compiled.FindStringSubmatch(value)
+	if sub == nil {
+		return nil, false
+	}
+	groups := make(map[string]string)
+	addCaptures(groups, m.compiled, sub)
+	return groups, true
+}
+
+type fieldMatcherRef struct {
+	field   string
+	matcher *FieldMatcher
+}
+
+func (ev *Event) fieldMatchers() []fieldMatcherRef {
+	refs := []fieldMatcherRef{
+		{FieldClass, ev.Class},
+		{FieldTitle, ev.Title},
+		{FieldWorkspace, ev.Workspace},
+		{FieldMonitor, ev.Monitor},
+		{FieldInitialClass, ev.InitialClass},
+		{FieldInitialTitle, ev.InitialTitle},
+	}
+
+	present := refs[:0]
+	for _, ref := range refs {
+		if ref.matcher != nil {
+			present = append(present, ref)
+		}
+	}
+	return present
+}
+
+// HasMatcher reports whether the rule has a regex or at least one field
+// matcher. Rules without any are rejected by the loader.
+func (ev *Event) HasMatcher() bool {
+	return ev.Regex != "" || len(ev.fieldMatchers()) > 0
+}
+
+func matchedValue(data *EventData, field string) string {
+	// Numbered workspaces are often only reported by id.
+	if field == FieldWorkspace && data.WorkspaceName == "" {
+		return data.WorkspaceID
+	}
+	return data.Field(field)
+}
+
+func (m *FieldMatcher) String() string {
+	var parts []string
+	if m.Regex != "" {
+		parts = append(parts, "~"+m.Regex)
+	}
+	if m.Exact != "" {
+		parts = append(parts, "="+m.Exact)
+	}
+	if m.Prefix != "" {
+		parts = append(parts, "^"+m.Prefix)
+	}
+	s := strings.Join(parts, " ")
+	if m.Not {
+		s = "!(" + s + ")"
+	}
+	return s
+}
+
+// MatchSummary renders the rule's matchers on one line for log output.
+func (ev *Event) MatchSummary() string {
+	var parts []string
+	if ev.Regex != "" {
+		parts = append(parts, ev.Regex)
+	}
+	for _, ref := range ev.fieldMatchers() {
+		parts = append(parts, ref.field+" "+ref.matcher.String())
+	}
+	return strings.Join(parts, ", ")
+}
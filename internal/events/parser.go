@@ -2,27 +2,192 @@ package events
 
 import "strings"
 
+// Field names used by the parser table. Each socket2 payload is split on
+// commas into these fields in order; the last field receives the remainder
+// so titles and workspace names containing commas stay intact.
+const (
+	FieldAddress      = "address"
+	FieldWorkspaceID  = "workspace_id"
+	FieldWorkspace    = "workspace"
+	FieldClass        = "class"
+	FieldTitle        = "title"
+	FieldMonitor      = "monitor"
+	FieldMonitorID    = "monitor_id"
+	FieldDescription  = "description"
+	FieldState        = "state"
+	FieldGroup        = "group"
+	FieldLayer        = "layer"
+	FieldSubmap       = "submap"
+	FieldKeyboard     = "keyboard"
+	FieldLayout       = "layout"
+	FieldOwner        = "owner"
+	FieldInitialClass = "initial_class"
+	FieldInitialTitle = "initial_title"
+)
+
+type eventSpec struct {
+	fields  []string
+	content string
+}
+
+// eventSpecs describes every event emitted on Hyprland's socket2.
+// content names the field exposed as EventData.Content for `regex` rules;
+// when empty the raw payload is used.
+var eventSpecs = map[string]eventSpec{
+	"workspace":          {fields: []string{FieldWorkspace}},
+	"workspacev2":        {fields: []string{FieldWorkspaceID, FieldWorkspace}},
+	"focusedmon":         {fields: []string{FieldMonitor, FieldWorkspace}},
+	"focusedmonv2":       {fields: []string{FieldMonitor, FieldWorkspaceID}},
+	"activewindow":       {fields: []string{FieldClass, FieldTitle}, content: FieldTitle},
+	"activewindowv2":     {fields: []string{FieldAddress}},
+	"fullscreen":         {fields: []string{FieldState}},
+	"monitorremoved":     {fields: []string{FieldMonitor}},
+	"monitorremovedv2":   {fields: []string{FieldMonitorID, FieldMonitor, FieldDescription}},
+	"monitoradded":       {fields: []string{FieldMonitor}},
+	"monitoraddedv2":     {fields: []string{FieldMonitorID, FieldMonitor, FieldDescription}},
+	"createworkspace":    {fields: []string{FieldWorkspace}},
+	"createworkspacev2":  {fields: []string{FieldWorkspaceID, FieldWorkspace}},
+	"destroyworkspace":   {fields: []string{FieldWorkspace}},
+	"destroyworkspacev2": {fields: []string{FieldWorkspaceID, FieldWorkspace}},
+	"moveworkspace":      {fields: []string{FieldWorkspace, FieldMonitor}},
+	"moveworkspacev2":    {fields: []string{FieldWorkspaceID, FieldWorkspace, FieldMonitor}},
+	"renameworkspace":    {fields: []string{FieldWorkspaceID, FieldWorkspace}},
+	"activespecial":      {fields: []string{FieldWorkspace, FieldMonitor}},
+	"activespecialv2":    {fields: []string{FieldWorkspaceID, FieldWorkspace, FieldMonitor}},
+	"activelayout":       {fields: []string{FieldKeyboard, FieldLayout}},
+	"openwindow":         {fields: []string{FieldAddress, FieldWorkspace, FieldClass, FieldTitle}, content: FieldTitle},
+	"closewindow":        {fields: []string{FieldAddress}},
+	"movewindow":         {fields: []string{FieldAddress, FieldWorkspace}},
+	"movewindowv2":       {fields: []string{FieldAddress, FieldWorkspaceID, FieldWorkspace}},
+	"openlayer":          {fields: []string{FieldLayer}},
+	"closelayer":         {fields: []string{FieldLayer}},
+	"submap":             {fields: []string{FieldSubmap}},
+	"changefloatingmode": {fields: []string{FieldAddress, FieldState}},
+	"urgent":             {fields: []string{FieldAddress}},
+	"screencast":         {fields: []string{FieldState, FieldOwner}},
+	"windowtitle":        {fields: []string{FieldAddress}},
+	"windowtitlev2":      {fields: []string{FieldAddress, FieldTitle}, content: FieldTitle},
+	"togglegroup":        {fields: []string{FieldState, FieldGroup}},
+	"moveintogroup":      {fields: []string{FieldAddress}},
+	"moveoutofgroup":     {fields: []string{FieldAddress}},
+	"ignoregrouplock":    {fields: []string{FieldState}},
+	"lockgroups":         {fields: []string{FieldState}},
+	"configreloaded":     {},
+	"pin":                {fields: []string{FieldAddress, FieldState}},
+	"minimized":          {fields: []string{FieldAddress, FieldState}},
+	"bell":               {fields: []string{FieldAddress}},
+}
+
+// IsKnownEvent reports whether name is part of the socket2 event catalogue.
+func IsKnownEvent(name string) bool {
+	_, ok := eventSpecs[name]
+	return ok
+}
+
 func ParseEventData(eventName, rawData string) *EventData {
-	switch eventName {
-	case "windowtitlev2":
-		parts := strings.SplitN(rawData, ",", 2)
-		if len(parts) >= 2 {
-			return &EventData{WindowID: parts[0], Content: parts[1]}
-		}
-	case "openwindow":
-		parts := strings.SplitN(rawData, ",", 4)
-		if len(parts) >= 4 {
-			return &EventData{WindowID: parts[0], Content: parts[3]}
-		}
-	case "activewindow":
-		if i := strings.Index(rawData, ","); i != -1 {
-			return &EventData{
-				WindowID: strings.TrimSpace(rawData[:i]),
-				Content:  strings.TrimSpace(rawData[i+1:]),
-			}
-		}
-		return &EventData{WindowID: strings.TrimSpace(rawData), Content: ""}
+	data := &EventData{Name: eventName, Raw: rawData, Content: rawData}
+
+	spec, ok := eventSpecs[eventName]
+	if !ok || len(spec.fields) == 0 {
+		return data
+	}
+
+	parts := strings.SplitN(rawData, ",", len(spec.fields))
+	for i, value := range parts {
+		data.set(spec.fields[i], value)
 	}
 
-	return &EventData{WindowID: "", Content: rawData}
+	// An openwindow event describes the window as it was created.
+	if eventName == "openwindow" {
+		data.InitialClass = data.Class
+		data.InitialTitle = data.Title
+	}
+
+	if spec.content != "" && len(parts) == len(spec.fields) {
+		data.Content = data.Field(spec.content)
+	}
+	return data
+}
+
+func (d *EventData) set(field, value string) {
+	switch field {
+	case FieldAddress:
+		d.WindowID = strings.TrimPrefix(value, "0x")
+	case FieldWorkspaceID:
+		d.WorkspaceID = value
+	case FieldWorkspace:
+		d.WorkspaceName = value
+	case FieldClass:
+		d.Class = value
+	case FieldTitle:
+		d.Title = value
+	case FieldMonitor:
+		d.Monitor = value
+	case FieldMonitorID:
+		d.MonitorID = value
+	case FieldDescription:
+		d.Description = value
+	case FieldState:
+		d.State = value
+	case FieldGroup:
+		d.Group = value
+		first, _, _ := strings.Cut(value, ",")
+		d.WindowID = strings.TrimPrefix(first, "0x")
+	case FieldLayer:
+		d.Layer = value
+	case FieldSubmap:
+		d.Submap = value
+	case FieldKeyboard:
+		d.Keyboard = value
+	case FieldLayout:
+		d.Layout = value
+	case FieldOwner:
+		d.Owner = value
+	case FieldInitialClass:
+		d.InitialClass = value
+	case FieldInitialTitle:
+		d.InitialTitle = value
+	}
+}
+
+// Field returns the value of the named field, or "" if the event does not
+// carry it.
+func (d *EventData) Field(field string) string {
+	switch field {
+	case FieldAddress:
+		return d.WindowID
+	case FieldWorkspaceID:
+		return d.WorkspaceID
+	case FieldWorkspace:
+		return d.WorkspaceName
+	case FieldClass:
+		return d.Class
+	case FieldTitle:
+		return d.Title
+	case FieldMonitor:
+		return d.Monitor
+	case FieldMonitorID:
+		return d.MonitorID
+	case FieldDescription:
+		return d.Description
+	case FieldState:
+		return d.State
+	case FieldGroup:
+		return d.Group
+	case FieldLayer:
+		return d.Layer
+	case FieldSubmap:
+		return d.Submap
+	case FieldKeyboard:
+		return d.Keyboard
+	case FieldLayout:
+		return d.Layout
+	case FieldOwner:
+		return d.Owner
+	case FieldInitialClass:
+		return d.InitialClass
+	case FieldInitialTitle:
+		return d.InitialTitle
+	}
+	return ""
 }
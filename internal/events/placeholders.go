@@ -0,0 +1,106 @@
+package events
+
+import (
+	"regexp"
+	"strings"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// placeholderFields maps the upper-case event placeholders to EventData fields.
+var placeholderFields = map[string]string{
+	"ADDRESS":       FieldAddress,
+	"WORKSPACE_ID":  FieldWorkspaceID,
+	"WORKSPACE":     FieldWorkspace,
+	"CLASS":         FieldClass,
+	"TITLE":         FieldTitle,
+	"MONITOR":       FieldMonitor,
+	"MONITOR_ID":    FieldMonitorID,
+	"DESCRIPTION":   FieldDescription,
+	"STATE":         FieldState,
+	"GROUP":         FieldGroup,
+	"LAYER":         FieldLayer,
+	"SUBMAP":        FieldSubmap,
+	"KEYBOARD":      FieldKeyboard,
+	"LAYOUT":        FieldLayout,
+	"OWNER":         FieldOwner,
+	"INITIAL_CLASS": FieldInitialClass,
+	"INITIAL_TITLE": FieldInitialTitle,
+}
+
+// Placeholders returns the values substituted into commands: one entry per
+// event field plus {WINDOW_ID}, {EVENT}, {CONTENT} and {RAW}.
+func (d *EventData) Placeholders() map[string]string {
+	vars := map[string]string{
+		"WINDOW_ID": d.WindowID,
+		"EVENT":     d.Name,
+		"CONTENT":   d.Content,
+		"RAW":       d.Raw,
+	}
+	for name, field := range placeholderFields {
+		vars[name] = d.Field(field)
+	}
+	return vars
+}
+
+// expandPlaceholders replaces every {NAME} found in vars. Unknown names are
+// left untouched.
+func expandPlaceholders(template string, vars map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(template, func(m string) string {
+		if value, ok := vars[m[1:len(m)-1]]; ok {
+			return value
+		}
+		return m
+	})
+}
+
+// shellPlaceholders replaces every {NAME} of a shell script found in vars
+// with a reference to the environment variable HT_NAME, and returns the
+// variables to set. The values never become part of the script, so event
+// data cannot be parsed as shell code. ${NAME} is left to the shell.
+func shellPlaceholders(script string, vars map[string]string) (string, []string) {
+	var env []string
+	seen := make(map[string]bool)
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(script, -1) {
+		name := script[loc[2]:loc[3]]
+		value, ok := vars[name]
+		if !ok || shellParameter(script, loc[0]) {
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			env = append(env, "HT_"+name+"="+value)
+		}
+		b.WriteString(script[last:loc[0]])
+		b.WriteString(shellReference("HT_" + name))
+		last = loc[1]
+	}
+	b.WriteString(script[last:])
+	return b.String(), env
+}
+
+// shellReference expands to the value of the set variable name as a
+// single word, whether it appears unquoted, inside double quotes, in a
+// command substitution or in a here-document: the quotes nested in ${...+}
+// apply in all of them. Like any variable, it is not expanded inside
+// single quotes.
+func shellReference(name string) string {
+	return "${" + name + `+"${` + name + `}"}`
+}
+
+// shellParameter reports whether the brace at i opens a shell parameter
+// expansion such as ${HOME}.
+func shellParameter(template string, i int) bool {
+	return i > 0 && template[i-1] == '$'
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
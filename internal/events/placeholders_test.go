@@ -0,0 +1,45 @@
+package events
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestShellPlaceholders runs shell commands with a hostile title and checks
+// that it comes out as data in every quoting context.
+func TestShellPlaceholders(t *testing.T) {
+	const title = `x; echo INJECTED $(echo SUB) ` + "`echo TICK`" + ` "q" 's *`
+	vars := map[string]string{"TITLE": title, "CLASS": ""}
+	t.Setenv("HOME", "/home/test")
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"unquoted", `printf '%s|' {TITLE}`, title + "|"},
+		{"single quotes", `printf '%s|' '{TITLE}'`, shellReference("HT_TITLE") + "|"},
+		{"double quotes", `printf '%s|' "title: {TITLE}"`, "title: " + title + "|"},
+		{"command substitution", `printf '%s|' "$(printf '<%s>' {TITLE})"`, "<" + title + ">|"},
+		{"backticks", "printf '%s|' \"`printf '<%s>' {TITLE}`\"", "<" + title + ">|"},
+		{"heredoc", "cat <<EOF\n{TITLE}\nEOF", title + "\n"},
+		{"empty value", `printf '[%s]' {CLASS} "{CLASS}"`, "[][]"},
+		{"shell variable", `printf '%s|' ${HOME} "${TITLE}"`, "/home/test||"},
+		{"unknown placeholder", `printf '%s|' {NOPE}`, "{NOPE}|"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, env := shellPlaceholders(tt.script, vars)
+			cmd := exec.Command("sh", "-c", script)
+			cmd.Env = append(os.Environ(), env...)
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("%s: %v", script, err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("%s\n got %q\nwant %q", script, out, tt.want)
+			}
+		})
+	}
+}
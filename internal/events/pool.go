@@ -0,0 +1,148 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	defaultWorkers   = 4
+	defaultQueueSize = 128
+)
+
+type job struct {
+	event    *Event
+	data     *EventData
+	captures map[string]string
+}
+
+// workerPool runs rule actions off the listener goroutine. The queue is
+// bounded; when it is full new jobs are rejected rather than blocking the
+// reader of socket2.
+//
+// A sequential rule with a job in progress gets further jobs queued in
+// serial instead, by rule identity; the worker running it takes them one by
+// one when done. No worker ever waits for a sequential rule.
+type workerPool struct {
+	jobs       chan job
+	dispatcher func() Dispatcher
+	onFailure  func(Execution)
+	history    *History
+	wg         sync.WaitGroup
+
+	mu     sync.Mutex
+	serial map[string][]job
+}
+
+func newWorkerPool(workers, queueSize int, dispatcher func() Dispatcher, onFailure func(Execution), history *History) *workerPool {
+	wp := &workerPool{
+		jobs:       make(chan job, queueSize),
+		dispatcher: dispatcher,
+		onFailure:  onFailure,
+		history:    history,
+		serial:     make(map[string][]job),
+	}
+	for i := 0; i < workers; i++ {
+		go wp.work()
+	}
+	return wp
+}
+
+func (wp *workerPool) submit(j job) error {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	rule := j.event.Identity()
+	if j.event.Sequential {
+		if backlog, busy := wp.serial[rule]; busy {
+			if len(backlog) >= cap(wp.jobs) {
+				return fmt.Errorf("execution queue of %s full, dropping it", rule)
+			}
+			wp.wg.Add(1)
+			wp.serial[rule] = append(backlog, j)
+			return nil
+		}
+	}
+
+	wp.wg.Add(1)
+	select {
+	case wp.jobs <- j:
+		if j.event.Sequential {
+			wp.serial[rule] = nil
+		}
+		return nil
+	default:
+		wp.wg.Done()
+		return fmt.Errorf("execution queue full, dropping %s", rule)
+	}
+}
+
+// next returns the following job of a sequential rule, or marks the rule
+// idle when it has none.
+func (wp *workerPool) next(rule string) (job, bool) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	backlog := wp.serial[rule]
+	if len(backlog) == 0 {
+		delete(wp.serial, rule)
+		return job{}, false
+	}
+	wp.serial[rule] = backlog[1:]
+	return backlog[0], true
+}
+
+// wait blocks until every submitted job has finished.
+func (wp *workerPool) wait() {
+	wp.wg.Wait()
+}
+
+func (wp *workerPool) work() {
+	for j := range wp.jobs {
+		for {
+			wp.run(j)
+			if !j.event.Sequential {
+				break
+			}
+			var ok bool
+			if j, ok = wp.next(j.event.Identity()); !ok {
+				break
+			}
+		}
+	}
+}
+
+// run executes a job's dispatch list, then its command unless the
+// dispatch failed.
+func (wp *workerPool) run(j job) {
+	defer wp.wg.Done()
+	ev := j.event
+	if len(ev.Dispatch) > 0 {
+		rec := newExecution(ev, j.data, "dispatch")
+		err := ev.ExecuteDispatch(wp.dispatcher(), j.data, j.captures, rec)
+		if !wp.record(rec, err) {
+			return
+		}
+	}
+	if ev.HasCommand() {
+		rec := newExecution(ev, j.data, "command")
+		err := ev.ExecuteCommand(j.data, j.captures, rec)
+		wp.record(rec, err)
+	}
+}
+
+// record adds an execution to the history and reports whether it
+// succeeded. Failures are also logged and passed to onFailure.
+func (wp *workerPool) record(rec *Execution, err error) bool {
+	rec.finish(err)
+	wp.history.add(*rec)
+	if !rec.Failed() {
+		return true
+	}
+	fmt.Printf("Rule %s failed on %s (exit %d): %s: %v\n", rec.Rule, rec.Event, rec.ExitCode, rec.Action, err)
+	if rec.Stderr != "" {
+		fmt.Printf("  stderr: %s\n", rec.Stderr)
+	}
+	wp.onFailure(*rec)
+	return false
+}
@@ -1,82 +1,254 @@
 package events
 
 import (
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Processor struct {
-	registry     *Registry
+	// mu serialises event handling; replays run on another goroutine than
+	// the socket listener.
+	mu           sync.Mutex
+	registry     atomic.Pointer[Registry]
 	deduplicator *deduplicationManager
+	timing       *timingState
+	dispatcher   Dispatcher
+	state        StateTracker
+	pool         *workerPool
+	history      History
+	stats        statsCounter
+	controls     controls
+	subscribers  subscribers
 }
 
-type deduplicationManager struct {
-	recentExecutions  []EventExecution
-	deduplicationTime time.Duration
+func NewProcessor(registry *Registry) *Processor {
+	p := &Processor{
+		deduplicator: newDeduplicationManager(),
+		timing:       newTimingState(),
+	}
+	p.pool = newWorkerPool(defaultWorkers, defaultQueueSize,
+		func() Dispatcher { return p.dispatcher },
+		p.recordFailure, &p.history)
+	p.registry.Store(registry)
+	return p
 }
 
-func newDeduplicationManager() *deduplicationManager {
-	return &deduplicationManager{
-		recentExecutions:  make([]EventExecution, 0),
-		deduplicationTime: 2 * time.Second,
-	}
+// SetRegistry atomically replaces the rule set. An event being processed
+// finishes with the registry it started with; the next one sees the new
+// registry. The old rule set is never visible partially cleared.
+func (p *Processor) SetRegistry(r *Registry) {
+	p.registry.Store(r)
 }
 
-func (dm *deduplicationManager) wasRecentlyExecuted(windowID, eventName, regex string) bool {
-	now := time.Now()
+func (p *Processor) Registry() *Registry {
+	return p.registry.Load()
+}
 
-	filtered := make([]EventExecution, 0)
-	for _, exec := range dm.recentExecutions {
-		if now.Sub(exec.Timestamp) <= dm.deduplicationTime {
-			filtered = append(filtered, exec)
-		}
-	}
-	dm.recentExecutions = filtered
+// SetDispatcher sets where `dispatch` actions are sent. It must be called
+// before events are processed.
+func (p *Processor) SetDispatcher(d Dispatcher) {
+	p.dispatcher = d
+}
 
-	for _, exec := range dm.recentExecutions {
-		if exec.WindowID == windowID && exec.EventName == eventName && exec.Regex == regex {
-			return true
-		}
+// SetStateTracker sets the window model used to fill in fields an event
+// does not carry, such as the class on windowtitlev2.
+func (p *Processor) SetStateTracker(t StateTracker) {
+	p.state = t
+}
+
+func (p *Processor) ProcessEvent(eventName, rawData string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stats.update(func(s *Stats) { s.Received[eventName]++ })
+	eventData := ParseEventData(eventName, rawData)
+	if p.state != nil {
+		// Enrich before updating so closewindow still sees the window.
+		p.enrich(eventData)
+		p.state.Update(eventData)
 	}
-	return false
+	err := p.run(eventData, nil, p.startTrace(eventData, false))
+	if eventName == "closewindow" {
+		p.deduplicator.forgetWindow(eventData.WindowID)
+		p.timing.forgetWindow(eventData.WindowID)
+	}
+	return err
 }
 
-func (dm *deduplicationManager) record(windowID, eventName, regex string) {
-	dm.recentExecutions = append(dm.recentExecutions, EventExecution{
-		WindowID:  windowID,
-		EventName: eventName,
-		Regex:     regex,
-		Timestamp: time.Now(),
-	})
+// ReplayEvent runs a synthetic event, built from state that already exists
+// in Hyprland, through the rules accepted by filter (all rules if nil). The
+// state tracker is not updated, but deduplication applies as usual.
+func (p *Processor) ReplayEvent(eventData *EventData, filter func(*Event) bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != nil {
+		p.enrich(eventData)
+	}
+	return p.run(eventData, filter, p.startTrace(eventData, true))
 }
 
-func NewProcessor(registry *Registry) *Processor {
-	return &Processor{
-		registry:     registry,
-		deduplicator: newDeduplicationManager(),
+// startTrace returns a trace to fill in while running the event, or nil
+// when nobody watches.
+func (p *Processor) startTrace(eventData *EventData, replay bool) *Trace {
+	if !p.subscribers.active() {
+		return nil
 	}
+	return newTrace(eventData, replay)
 }
 
-func (p *Processor) ProcessEvent(eventName, rawData string) error {
-	eventData := ParseEventData(eventName, rawData)
-	events := p.registry.GetEventsByName(eventName)
+// run hands every matching rule to the worker pool, in registry order.
+// Rules switched off at runtime only count the match. A matching final rule
+// stops the rules with a lower priority than its own, even when
+// deduplication suppresses its own actions; rules of the same priority
+// still run, whatever their order. A rule that cannot be queued does not
+// stop the others; all such errors are joined. The outcome of every
+// matching rule is added to trace, if not nil, which is then published to
+// subscribers.
+func (p *Processor) run(eventData *EventData, filter func(*Event) bool, trace *Trace) error {
+	eventName := eventData.Name
+	events := p.registry.Load().GetEventsByName(eventName)
 
+	var (
+		errs      []error
+		final     bool
+		threshold int
+	)
 	for _, event := range events {
-		if !event.Match(eventData.Content) {
+		if final && event.Priority < threshold {
+			break // rules are sorted by priority
+		}
+		if !event.IsEnabled() || (filter != nil && !filter(event)) {
 			continue
 		}
-		if p.deduplicator.wasRecentlyExecuted(eventData.WindowID, eventName, event.Regex) {
+		captures, ok := event.MatchCaptures(eventData)
+		if !ok {
+			if event.Debounce > 0 {
+				p.cancelDebounce(event, eventData.WindowID)
+			}
 			continue
 		}
-		if err := event.ExecuteCommand(eventData.WindowID); err != nil {
-			return fmt.Errorf("command execution failed for %s: %w", event.Name, err)
+		outcome := RuleOutcome{Rule: event.Identity()}
+		switch {
+		case p.skipped(event):
+			p.countSkipped(event)
+			outcome.Outcome = OutcomeSkipped
+		case event.Debounce > 0:
+			p.debounce(event, eventData, captures)
+			outcome.Outcome = OutcomeDebounced
+		default:
+			var err error
+			outcome.Outcome, err = p.fire(event, eventData, captures)
+			if err != nil {
+				errs = append(errs, err)
+				outcome.Error = err.Error()
+			}
+		}
+		if trace != nil {
+			trace.Rules = append(trace.Rules, outcome)
+		}
+		if outcome.Outcome != OutcomeSkipped && event.Final && !final {
+			final, threshold = true, event.Priority
+		}
+	}
+
+	if trace != nil {
+		p.subscribers.publish(trace)
+	}
+	return errors.Join(errs...)
+}
+
+// fire applies deduplication and throttling to a matched rule and queues
+// its actions, returning the outcome. It must be called with p.mu held.
+func (p *Processor) fire(event *Event, eventData *EventData, captures map[string]string) (string, error) {
+	if p.deduplicator.suppressed(event, eventData.WindowID) {
+		p.stats.update(func(s *Stats) { s.Suppressed++ })
+		return OutcomeSuppressed, nil
+	}
+	if p.timing.throttled(event, eventData.WindowID) {
+		p.stats.update(func(s *Stats) { s.Throttled++ })
+		return OutcomeThrottled, nil
+	}
+	// Actions run on the worker pool so a slow command never holds up the
+	// listener; dedup is recorded now to cover the in-flight run.
+	p.deduplicator.record(event, eventData.WindowID)
+	if err := p.pool.submit(job{event: event, data: eventData, captures: captures}); err != nil {
+		// The rejected job is recorded like a failed execution.
+		rec := newExecution(event, eventData, "queue")
+		rec.finish(err)
+		p.history.add(*rec)
+		p.recordFailure(*rec)
+		return OutcomeError, fmt.Errorf("rule %s: %w", event.Identity(), err)
+	}
+	p.stats.update(func(s *Stats) { s.Fired++ })
+	p.stats.updateRule(event.Identity(), func(rs *RuleStats) {
+		rs.Fired++
+		rs.LastFired = time.Now()
+	})
+	return OutcomeFired, nil
+}
+
+// countSkipped counts a match of a rule that is switched off at runtime.
+func (p *Processor) countSkipped(event *Event) {
+	p.stats.update(func(s *Stats) { s.Skipped++ })
+	p.stats.updateRule(event.Identity(), func(rs *RuleStats) { rs.Skipped++ })
+}
+
+func (p *Processor) recordFailure(e Execution) {
+	p.stats.update(func(s *Stats) {
+		s.Failures++
+		s.LastError = &e
+	})
+	p.stats.updateRule(e.Rule, func(rs *RuleStats) { rs.Failures++ })
+}
+
+// Stats returns a snapshot of the processor's counters.
+func (p *Processor) Stats() Stats {
+	return p.stats.snapshot()
+}
+
+// RuleStats returns the counters of the rule with the given identity.
+func (p *Processor) RuleStats(rule string) RuleStats {
+	return p.stats.rule(rule)
+}
+
+// History returns the execution log of the processor's actions.
+func (p *Processor) History() *History {
+	return &p.history
+}
+
+// Wait blocks until every action submitted so far has finished.
+func (p *Processor) Wait() {
+	p.pool.wait()
+}
+
+func (p *Processor) enrich(data *EventData) {
+	if data.WindowID == "" {
+		return
+	}
+	info, ok := p.state.Window(data.WindowID)
+	if !ok {
+		return
+	}
+
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
 		}
-		p.deduplicator.record(eventData.WindowID, eventName, event.Regex)
 	}
-	return nil
+	fill(&data.Class, info.Class)
+	fill(&data.Title, info.Title)
+	fill(&data.InitialClass, info.InitialClass)
+	fill(&data.InitialTitle, info.InitialTitle)
+	fill(&data.WorkspaceID, info.WorkspaceID)
+	fill(&data.WorkspaceName, info.WorkspaceName)
+	fill(&data.Monitor, info.Monitor)
 }
 
-var DefaultProcessor = NewProcessor(DefaultRegistry)
+var DefaultProcessor = NewProcessor(NewRegistry())
 
 func ProcessEvent(eventName, data string) error {
 	return DefaultProcessor.ProcessEvent(eventName, data)
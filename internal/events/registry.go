@@ -1,55 +1,142 @@
 package events
 
+import (
+	"sort"
+	"time"
+)
+
+// Registry holds a complete rule set. A registry is filled once by the
+// loaders and then published to a Processor with SetRegistry; it must not be
+// modified after that. Reloading builds a fresh registry instead.
 type Registry struct {
 	events            map[string][]*Event
 	builtinEvents     map[string][]*Event
+	byID              map[string]*Event
 	skipBuiltinEvents bool
+	sources           []string
+	created           time.Time
 }
 
-var DefaultRegistry = NewRegistry()
-
 func NewRegistry() *Registry {
 	return &Registry{
 		events:            make(map[string][]*Event),
 		builtinEvents:     make(map[string][]*Event),
+		byID:              make(map[string]*Event),
 		skipBuiltinEvents: false,
+		created:           time.Now(),
 	}
 }
 
+// AddSource records a config file the rules were loaded from.
+func (r *Registry) AddSource(path string) {
+	r.sources = append(r.sources, path)
+}
+
+// Sources lists the config files recorded with AddSource, in load order.
+func (r *Registry) Sources() []string {
+	return r.sources
+}
+
+// Created returns when the registry was built, i.e. when its configuration
+// was loaded.
+func (r *Registry) Created() time.Time {
+	return r.created
+}
+
 func (r *Registry) RegisterBuiltin(event *Event) {
-	if r.builtinEvents[event.Name] == nil {
-		r.builtinEvents[event.Name] = make([]*Event, 0)
+	event.Compile()
+	if event.Origin == "" {
+		event.Origin = "builtin"
+		for _, list := range r.builtinEvents {
+			event.Position += len(list)
+		}
+	}
+	r.builtinEvents[event.Name] = insertRule(r.builtinEvents[event.Name], event)
+	if event.ID != "" {
+		r.byID[event.ID] = event
 	}
-	r.builtinEvents[event.Name] = append(r.builtinEvents[event.Name], event)
 
 	if !r.skipBuiltinEvents {
-		if r.events[event.Name] == nil {
-			r.events[event.Name] = make([]*Event, 0)
-		}
-		r.events[event.Name] = append(r.events[event.Name], event)
+		r.events[event.Name] = insertRule(r.events[event.Name], event)
 	}
 }
 
+// RegisterExplicit adds a user rule. A rule whose ID is already registered
+// replaces that rule where it stood instead of being appended; replacing a
+// builtin skipped by SetSkipBuiltinEvents leaves it inactive.
 func (r *Registry) RegisterExplicit(event *Event) {
-	if r.events[event.Name] == nil {
-		r.events[event.Name] = make([]*Event, 0)
+	event.Compile()
+	if event.ID == "" {
+		r.events[event.Name] = insertRule(r.events[event.Name], event)
+		return
+	}
+
+	old, exists := r.byID[event.ID]
+	r.byID[event.ID] = event
+	if !exists {
+		r.events[event.Name] = insertRule(r.events[event.Name], event)
+		return
 	}
-	r.events[event.Name] = append(r.events[event.Name], event)
+
+	list := r.events[old.Name]
+	for i, ev := range list {
+		if ev != old {
+			continue
+		}
+		if old.Name == event.Name {
+			list[i] = event
+			sortRules(list)
+		} else {
+			r.events[old.Name] = append(list[:i:i], list[i+1:]...)
+			r.events[event.Name] = insertRule(r.events[event.Name], event)
+		}
+		return
+	}
+}
+
+// insertRule adds a rule keeping the list in evaluation order: higher
+// priority first, then registration order. Builtins register first, then
+// the auto-config files and -c, each file in name order and its rules in
+// file order.
+func insertRule(list []*Event, event *Event) []*Event {
+	list = append(list, event)
+	sortRules(list)
+	return list
+}
+
+func sortRules(list []*Event) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Priority > list[j].Priority
+	})
 }
 
 func (r *Registry) SetSkipBuiltinEvents(skip bool) {
 	r.skipBuiltinEvents = skip
 }
 
-func (r *Registry) Clear() {
-	r.events = make(map[string][]*Event)
-	r.builtinEvents = make(map[string][]*Event)
+// GetEventByID returns the rule registered under id, including builtins
+// skipped by SetSkipBuiltinEvents.
+func (r *Registry) GetEventByID(id string) (*Event, bool) {
+	ev, ok := r.byID[id]
+	return ev, ok
 }
 
+// GetEventsByName returns the rules for an event in evaluation order.
 func (r *Registry) GetEventsByName(name string) []*Event {
 	return r.events[name]
 }
 
+// Contains reports whether the registry has an active rule with the same
+// configuration as ev.
+func (r *Registry) Contains(ev *Event) bool {
+	for _, other := range r.events[ev.Name] {
+		if other.Fingerprint() == ev.Fingerprint() {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Registry) GetAllEvents() map[string][]*Event {
 	return r.events
 }
@@ -59,5 +146,30 @@ func (r *Registry) GetBuiltinEvents() map[string][]*Event {
 }
 
 func GetAllEvents() map[string][]*Event {
-	return DefaultRegistry.GetAllEvents()
+	return DefaultProcessor.Registry().GetAllEvents()
+}
+
+// Clone returns a deep copy of the rule's configuration, suitable for
+// decoding an override on top of it. The copy must be compiled again.
+func (ev *Event) Clone() *Event {
+	c := *ev
+	c.compiled, c.fingerprint = nil, ""
+	c.Dispatch = append([]string(nil), ev.Dispatch...)
+	c.Argv = append([]string(nil), ev.Argv...)
+	for _, m := range []**FieldMatcher{&c.Class, &c.Title, &c.Workspace, &c.Monitor, &c.InitialClass, &c.InitialTitle} {
+		if *m != nil {
+			copied := **m
+			copied.compiled = nil
+			*m = &copied
+		}
+	}
+	if ev.Enabled != nil {
+		enabled := *ev.Enabled
+		c.Enabled = &enabled
+	}
+	if ev.Dedupe != nil {
+		dedupe := *ev.Dedupe
+		c.Dedupe = &dedupe
+	}
+	return &c
 }
@@ -0,0 +1,67 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type countingDispatcher struct {
+	calls atomic.Int64
+}
+
+func (d *countingDispatcher) Dispatch(commands ...string) error {
+	d.calls.Add(1)
+	return nil
+}
+
+func testRegistry(class string) *Registry {
+	r := NewRegistry()
+	for i := range 2 {
+		r.RegisterExplicit(&Event{
+			Name:     "openwindow",
+			Class:    &FieldMatcher{Exact: class},
+			Dispatch: []string{fmt.Sprintf("focuswindow address:0x{WINDOW_ID} # %d", i)},
+			Dedupe:   &Dedupe{Mode: DedupeNone},
+		})
+	}
+	return r
+}
+
+// TestSetRegistryWhileProcessing swaps rule sets while events are being
+// processed. Run with -race; every event must see one complete rule set.
+func TestSetRegistryWhileProcessing(t *testing.T) {
+	d := &countingDispatcher{}
+	p := NewProcessor(testRegistry("kitty"))
+	p.SetDispatcher(d)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				p.SetRegistry(testRegistry("kitty"))
+			}
+		}
+	}()
+
+	const n = 500
+	for i := range n {
+		if err := p.ProcessEvent("openwindow", fmt.Sprintf("%x,1,kitty,term", i)); err != nil {
+			t.Fatalf("event %d: %v", i, err)
+		}
+		p.Wait()
+	}
+	close(done)
+	wg.Wait()
+
+	if got := d.calls.Load(); got != 2*n {
+		t.Errorf("dispatched %d times, want %d", got, 2*n)
+	}
+}
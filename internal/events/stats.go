@@ -0,0 +1,77 @@
+package events
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// Stats counts what the processor did since it started.
+type Stats struct {
+	// Received counts the socket2 events processed, by event name.
+	Received map[string]uint64 `json:"received"`
+	// Fired counts rules whose actions were queued.
+	Fired uint64 `json:"fired"`
+	// Failures counts actions that failed or could not be queued.
+	Failures uint64 `json:"failures"`
+	// Suppressed counts matches skipped by deduplication, Throttled those
+	// skipped by a rule's throttle.
+	Suppressed uint64 `json:"suppressed"`
+	Throttled  uint64 `json:"throttled"`
+	// Skipped counts matches of rules disabled at runtime or while paused.
+	Skipped   uint64     `json:"skipped"`
+	LastError *Execution `json:"last_error,omitempty"`
+}
+
+// RuleStats counts the activity of one rule, keyed by its identity so the
+// counters survive a reload.
+type RuleStats struct {
+	Fired     uint64    `json:"fired"`
+	Failures  uint64    `json:"failures"`
+	Skipped   uint64    `json:"skipped"`
+	LastFired time.Time `json:"last_fired,omitzero"`
+}
+
+// statsCounter is updated from the processor and from the worker pool.
+type statsCounter struct {
+	mu    sync.Mutex
+	stats Stats
+	rules map[string]RuleStats
+}
+
+func (sc *statsCounter) updateRule(rule string, f func(rs *RuleStats)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.rules == nil {
+		sc.rules = make(map[string]RuleStats)
+	}
+	rs := sc.rules[rule]
+	f(&rs)
+	sc.rules[rule] = rs
+}
+
+func (sc *statsCounter) rule(rule string) RuleStats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.rules[rule]
+}
+
+func (sc *statsCounter) update(f func(s *Stats)) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.stats.Received == nil {
+		sc.stats.Received = make(map[string]uint64)
+	}
+	f(&sc.stats)
+}
+
+func (sc *statsCounter) snapshot() Stats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	s := sc.stats
+	s.Received = maps.Clone(sc.stats.Received)
+	if s.Received == nil {
+		s.Received = make(map[string]uint64)
+	}
+	return s
+}
@@ -0,0 +1,135 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Rule outcomes reported in a Trace.
+const (
+	OutcomeFired      = "fired"
+	OutcomeDebounced  = "debounced"
+	OutcomeSuppressed = "suppressed"
+	OutcomeThrottled  = "throttled"
+	OutcomeSkipped    = "skipped"
+	OutcomeError      = "error"
+)
+
+// Trace describes how the processor handled one event, for live watchers.
+type Trace struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	// Raw is the line as received on socket2, event>>data. Replayed events
+	// are rebuilt from window state.
+	Raw    string            `json:"raw"`
+	Replay bool              `json:"replay,omitempty"`
+	Fields map[string]string `json:"fields"`
+	// Rules lists the rules that matched, in evaluation order, with what
+	// happened to each.
+	Rules []RuleOutcome `json:"rules"`
+}
+
+type RuleOutcome struct {
+	Rule    string `json:"rule"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+func newTrace(data *EventData, replay bool) *Trace {
+	t := &Trace{
+		Time:   time.Now(),
+		Event:  data.Name,
+		Raw:    data.Name + ">>" + data.Raw,
+		Replay: replay,
+		Fields: make(map[string]string),
+		Rules:  []RuleOutcome{},
+	}
+	for _, field := range placeholderFields {
+		if value := data.Field(field); value != "" {
+			t.Fields[field] = value
+		}
+	}
+	return t
+}
+
+// Subscription receives the traces of processed events accepted by its
+// filter. Traces are dropped, never waited for, when its buffer is full, so
+// a slow watcher cannot hold up the listener.
+type Subscription struct {
+	C       <-chan Trace
+	ch      chan Trace
+	filter  func(*Trace) bool
+	dropped atomic.Uint64
+	hub     *subscribers
+}
+
+// Dropped returns how many traces accepted by the filter were dropped so
+// far, and resets the count.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Swap(0)
+}
+
+// Close ends the subscription and closes C.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+type subscribers struct {
+	mu    sync.Mutex
+	subs  map[*Subscription]struct{}
+	count atomic.Int32
+}
+
+func (h *subscribers) add(buffer int, filter func(*Trace) bool) *Subscription {
+	ch := make(chan Trace, buffer)
+	s := &Subscription{C: ch, ch: ch, filter: filter, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = make(map[*Subscription]struct{})
+	}
+	h.subs[s] = struct{}{}
+	h.count.Add(1)
+	return s
+}
+
+func (h *subscribers) remove(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	h.count.Add(-1)
+	close(s.ch)
+}
+
+// active reports cheaply whether anyone listens, so traces are only built
+// when needed.
+func (h *subscribers) active() bool {
+	return h.count.Load() > 0
+}
+
+func (h *subscribers) publish(t *Trace) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if s.filter != nil && !s.filter(t) {
+			continue
+		}
+		select {
+		case s.ch <- *t:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe starts streaming the traces of processed events accepted by
+// filter (all if nil), buffering up to buffer of them. The filter runs on
+// the processing goroutine and must be quick.
+func (p *Processor) Subscribe(buffer int, filter func(*Trace) bool) *Subscription {
+	return p.subscribers.add(buffer, filter)
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"fmt"
+	"time"
+)
+
+// pendingAction is a debounced match waiting for its field to settle.
+type pendingAction struct {
+	timer    *time.Timer
+	event    *Event
+	data     *EventData
+	captures map[string]string
+}
+
+// timingState holds the per-rule, per-window debounce timers and throttle
+// timestamps. It is guarded by Processor.mu.
+type timingState struct {
+	pending   map[dedupeKey]*pendingAction
+	lastFired map[dedupeKey]time.Time
+}
+
+func newTimingState() *timingState {
+	return &timingState{
+		pending:   make(map[dedupeKey]*pendingAction),
+		lastFired: make(map[dedupeKey]time.Time),
+	}
+}
+
+// throttled reports whether the rule fired for the window less than its
+// throttle interval ago, and otherwise records a firing now.
+func (ts *timingState) throttled(ev *Event, windowID string) bool {
+	if ev.Throttle <= 0 {
+		return false
+	}
+	key := dedupeKey{rule: ev.stateKey(), window: windowID}
+	now := time.Now()
+	if last, ok := ts.lastFired[key]; ok && now.Sub(last) < time.Duration(ev.Throttle) {
+		return true
+	}
+	ts.lastFired[key] = now
+	return false
+}
+
+// forgetWindow cancels the window's pending actions and drops its throttle
+// timestamps.
+func (ts *timingState) forgetWindow(windowID string) {
+	for key, pa := range ts.pending {
+		if key.window == windowID {
+			pa.timer.Stop()
+			delete(ts.pending, key)
+		}
+	}
+	for key := range ts.lastFired {
+		if key.window == windowID {
+			delete(ts.lastFired, key)
+		}
+	}
+}
+
+// debounce (re)starts the rule's timer for the window with the latest
+// matching event. The action fires once no new event for that rule and
+// window arrived during the debounce interval.
+func (p *Processor) debounce(ev *Event, data *EventData, captures map[string]string) {
+	key := dedupeKey{rule: ev.stateKey(), window: data.WindowID}
+	if pa, ok := p.timing.pending[key]; ok {
+		pa.timer.Stop()
+	}
+
+	pa := &pendingAction{event: ev, data: data, captures: captures}
+	pa.timer = time.AfterFunc(time.Duration(ev.Debounce), func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.timing.pending[key] != pa {
+			return
+		}
+		delete(p.timing.pending, key)
+		// The rule may have been switched off while its action waited.
+		if p.skipped(pa.event) {
+			p.countSkipped(pa.event)
+			return
+		}
+		if _, err := p.fire(pa.event, pa.data, pa.captures); err != nil {
+			fmt.Printf("Processing error: %v\n", err)
+		}
+	})
+	p.timing.pending[key] = pa
+}
+
+// cancelDebounce drops the rule's pending action for the window, used when
+// the field changed to a value the rule no longer matches.
+func (p *Processor) cancelDebounce(ev *Event, windowID string) {
+	key := dedupeKey{rule: ev.stateKey(), window: windowID}
+	if pa, ok := p.timing.pending[key]; ok {
+		pa.timer.Stop()
+		delete(p.timing.pending, key)
+	}
+}
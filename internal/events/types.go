@@ -5,22 +5,88 @@ import (
 	"time"
 )
 
+// DefaultTimeout bounds a rule's command when it sets no timeout.
+const DefaultTimeout = 5 * time.Second
+
 type Event struct {
-	Name     string `json:"name"`
-	Regex    string `json:"regex"`
-	Command  string `json:"command"`
-	UseShell bool   `json:"use_shell"`
-	compiled *regexp.Regexp
+	// ID names the rule stably across reloads and lets config files
+	// override or disable it.
+	ID           string        `json:"id,omitempty"`
+	Enabled      *bool         `json:"enabled,omitempty"`
+	Name         string        `json:"name"`
+	Regex        string        `json:"regex,omitempty"`
+	Class        *FieldMatcher `json:"class,omitempty"`
+	Title        *FieldMatcher `json:"title,omitempty"`
+	Workspace    *FieldMatcher `json:"workspace,omitempty"`
+	Monitor      *FieldMatcher `json:"monitor,omitempty"`
+	InitialClass *FieldMatcher `json:"initial_class,omitempty"`
+	InitialTitle *FieldMatcher `json:"initial_title,omitempty"`
+	Dispatch     []string      `json:"dispatch,omitempty"`
+	Command      string        `json:"command,omitempty"`
+	Argv         []string      `json:"argv,omitempty"`
+	UseShell     bool          `json:"use_shell"`
+	ApplyOnStart bool          `json:"apply_on_start,omitempty"`
+	Timeout      Duration      `json:"timeout,omitempty"`
+	Sequential   bool          `json:"sequential,omitempty"`
+	Dedupe       *Dedupe       `json:"dedupe,omitempty"`
+	Debounce     Duration      `json:"debounce,omitempty"`
+	Throttle     Duration      `json:"throttle,omitempty"`
+	Priority     int           `json:"priority,omitempty"`
+	Final        bool          `json:"final,omitempty"`
+	// Origin is the config file a rule came from, or "builtin"; Position
+	// is its index there. Both are set at registration.
+	Origin   string `json:"-"`
+	Position int    `json:"-"`
+
+	compiled    *regexp.Regexp
+	fingerprint string
 }
 
+// EventData is a parsed socket2 line. Fields not carried by the event are
+// left empty; see eventSpecs for which event sets what.
 type EventData struct {
-	WindowID string
-	Content  string
+	Name          string
+	Raw           string
+	WindowID      string
+	WorkspaceID   string
+	WorkspaceName string
+	Class         string
+	Title         string
+	InitialClass  string
+	InitialTitle  string
+	Monitor       string
+	MonitorID     string
+	Description   string
+	State         string
+	Group         string
+	Layer         string
+	Submap        string
+	Keyboard      string
+	Layout        string
+	Owner         string
+	Content       string
+}
+
+// Dispatcher sends Hyprland dispatcher commands without spawning a process.
+// It is implemented by hyprland.RequestClient.
+type Dispatcher interface {
+	Dispatch(commands ...string) error
+}
+
+// WindowInfo is what a StateTracker knows about a window.
+type WindowInfo struct {
+	Class         string
+	Title         string
+	InitialClass  string
+	InitialTitle  string
+	WorkspaceID   string
+	WorkspaceName string
+	Monitor       string
 }
 
-type EventExecution struct {
-	WindowID  string
-	EventName string
-	Regex     string
-	Timestamp time.Time
+// StateTracker keeps a model of Hyprland's windows current from the event
+// stream. It is implemented by hyprland.State.
+type StateTracker interface {
+	Window(address string) (WindowInfo, bool)
+	Update(data *EventData)
 }
@@ -0,0 +1,169 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RuleError is one problem with a rule. Field is the JSON key it relates
+// to, or "" when it concerns the rule as a whole.
+type RuleError struct {
+	Field string
+	Err   error
+}
+
+func (e *RuleError) Error() string {
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *RuleError) Unwrap() error { return e.Err }
+
+// Validate compiles the rule and checks everything that would make it
+// misbehave at runtime. Unlike Compile it does not stop at the first
+// problem.
+func (ev *Event) Validate() []*RuleError {
+	var errs []*RuleError
+	add := func(field, format string, args ...any) {
+		errs = append(errs, &RuleError{Field: field, Err: fmt.Errorf(format, args...)})
+	}
+
+	switch {
+	case ev.Name == "":
+		add("name", "missing event name")
+	case !IsKnownEvent(ev.Name):
+		add("name", "unknown event %q", ev.Name)
+	}
+
+	if !ev.HasMatcher() {
+		add("", "needs a regex or at least one field matcher")
+	}
+	if !ev.HasAction() {
+		add("", "needs a command, an argv or a dispatch list")
+	}
+	if ev.Command != "" && len(ev.Argv) > 0 {
+		add("argv", "cannot be combined with command")
+	}
+	if len(ev.Argv) > 0 && ev.UseShell {
+		add("use_shell", "has no effect with argv")
+	}
+	if ev.Command != "" && !ev.UseShell {
+		if words, err := splitWords(ev.Command); err != nil {
+			add("command", "%v", err)
+		} else if len(words) == 0 {
+			add("command", "empty command")
+		}
+	}
+	if len(ev.Argv) > 0 && ev.Argv[0] == "" {
+		add("argv[0]", "empty program name")
+	}
+
+	if ev.Dedupe != nil {
+		if err := ev.Dedupe.validate(); err != nil {
+			add("dedupe", "%v", err)
+		}
+	}
+
+	if ev.Debounce < 0 {
+		add("debounce", "negative duration")
+	}
+	if ev.Throttle < 0 {
+		add("throttle", "negative duration")
+	}
+
+	if err := ev.Compile(); err != nil {
+		// Compile stops at the first bad regex; recompile each part to
+		// report all of them against their own key.
+		if ev.Regex != "" {
+			if err := (&FieldMatcher{Regex: ev.Regex}).compile(); err != nil {
+				add("regex", "invalid regex: %v", err)
+			}
+		}
+		for _, ref := range ev.fieldMatchers() {
+			if err := ref.matcher.compile(); err != nil {
+				add(ref.field, "invalid regex: %v", err)
+			}
+		}
+	}
+
+	for _, ref := range ev.fieldMatchers() {
+		m := ref.matcher
+		if m.Regex == "" && m.Exact == "" && m.Prefix == "" {
+			add(ref.field, "matcher needs regex, exact or prefix")
+		}
+	}
+
+	known := ev.knownPlaceholders()
+	for i, command := range ev.Dispatch {
+		field := fmt.Sprintf("dispatch[%d]", i)
+		if strings.TrimSpace(command) == "" {
+			add(field, "empty dispatch command")
+		}
+		for _, name := range unknownPlaceholders(command, known, false) {
+			add(field, "unknown placeholder {%s}", name)
+		}
+		if execDispatch(command) {
+			for _, m := range placeholderPattern.FindAllStringSubmatch(command, -1) {
+				if known[m[1]] {
+					add(field, "placeholder {%s} not allowed in exec, which Hyprland runs through a shell; use command instead", m[1])
+				}
+			}
+		}
+	}
+	for _, name := range unknownPlaceholders(ev.Command, known, ev.UseShell) {
+		add("command", "unknown placeholder {%s}", name)
+	}
+	for i, arg := range ev.Argv {
+		for _, name := range unknownPlaceholders(arg, known, false) {
+			add(fmt.Sprintf("argv[%d]", i), "unknown placeholder {%s}", name)
+		}
+	}
+
+	return errs
+}
+
+// knownPlaceholders lists the event placeholders plus every named group of
+// the rule's regexes.
+func (ev *Event) knownPlaceholders() map[string]bool {
+	known := map[string]bool{"WINDOW_ID": true, "EVENT": true, "CONTENT": true, "RAW": true}
+	for name := range placeholderFields {
+		known[name] = true
+	}
+	if ev.compiled != nil {
+		for _, name := range ev.compiled.SubexpNames() {
+			known[name] = true
+		}
+	}
+	for _, ref := range ev.fieldMatchers() {
+		if ref.matcher.compiled != nil && !ref.matcher.Not {
+			for _, name := range ref.matcher.compiled.SubexpNames() {
+				known[name] = true
+			}
+		}
+	}
+	return known
+}
+
+// execDispatch reports whether a dispatch command starts a process, which
+// Hyprland does with sh -c.
+func execDispatch(command string) bool {
+	fields := strings.Fields(command)
+	return len(fields) > 0 && (fields[0] == "exec" || fields[0] == "execr")
+}
+
+// unknownPlaceholders lists the {NAME}s of template missing from known.
+// With shell set, ${NAME} is a shell variable and not checked.
+func unknownPlaceholders(template string, known map[string]bool, shell bool) []string {
+	var unknown []string
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(template, -1) {
+		if shell && shellParameter(template, loc[0]) {
+			continue
+		}
+		if name := template[loc[2]:loc[3]]; !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,60 @@
+package hyprland
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"hyprtrigger/internal/events"
+)
+
+// ApplyToExistingWindows lists the current clients and runs each of them
+// through the processor as a synthetic openwindow followed by a
+// windowtitlev2, so rules also reach windows opened before the daemon.
+// Only rules accepted by filter run (all rules if nil).
+func ApplyToExistingWindows(rc *RequestClient, p *events.Processor, filter func(*events.Event) bool) error {
+	var clients []Window
+	if err := rc.Query("clients", &clients); err != nil {
+		return fmt.Errorf("failed to list clients: %w", err)
+	}
+
+	var errs []error
+	for _, w := range clients {
+		address := strings.TrimPrefix(w.Address, "0x")
+		workspaceID := strconv.Itoa(w.Workspace.ID)
+
+		open := &events.EventData{
+			Name:          "openwindow",
+			Raw:           strings.Join([]string{address, w.Workspace.Name, w.Class, w.Title}, ","),
+			WindowID:      address,
+			WorkspaceID:   workspaceID,
+			WorkspaceName: w.Workspace.Name,
+			Class:         w.Class,
+			Title:         w.Title,
+			InitialClass:  w.InitialClass,
+			InitialTitle:  w.InitialTitle,
+			Content:       w.Title,
+		}
+		if err := p.ReplayEvent(open, filter); err != nil {
+			errs = append(errs, err)
+		}
+
+		title := &events.EventData{
+			Name:          "windowtitlev2",
+			Raw:           address + "," + w.Title,
+			WindowID:      address,
+			WorkspaceID:   workspaceID,
+			WorkspaceName: w.Workspace.Name,
+			Class:         w.Class,
+			Title:         w.Title,
+			InitialClass:  w.InitialClass,
+			InitialTitle:  w.InitialTitle,
+			Content:       w.Title,
+		}
+		if err := p.ReplayEvent(title, filter); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
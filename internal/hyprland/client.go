@@ -3,41 +3,101 @@ package hyprland
 import (
 	"fmt"
 	"net"
-	"os"
+	"sync"
+	"time"
 )
 
 type Client struct {
-	conn net.Conn
+	opts      DiscoveryOptions
+	mu        sync.Mutex
+	conn      net.Conn
+	connected bool
+	closed    bool
+	since     time.Time
 }
 
-func NewClient() *Client {
-	return &Client{}
+func NewClient(opts DiscoveryOptions) *Client {
+	return &Client{opts: opts}
 }
 
+// Connect discovers the instance to use and connects to its event socket.
 func (c *Client) Connect() error {
-	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
-	instanceSig := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE")
-
-	socketPath := fmt.Sprintf("%s/hypr/%s/.socket2.sock", runtimeDir, instanceSig)
-	if instanceSig == "" {
-		socketPath = "/tmp/hypr/hyprland.sock2"
+	inst, err := Discover(c.opts)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Connecting to Hyprland socket: %s\n", socketPath)
+	fmt.Printf("Connecting to Hyprland socket: %s\n", inst.EventSocket())
 
-	conn, err := net.Dial("unix", socketPath)
+	conn, err := net.Dial("unix", inst.EventSocket())
 	if err != nil {
 		return fmt.Errorf("socket connection failed: %w", err)
 	}
 
-	c.conn = conn
+	setCurrentInstance(inst)
+	c.setConnection(conn)
 	fmt.Println("Connected to Hyprland, listening for events...")
 	return nil
 }
 
-func (c *Client) GetConnection() net.Conn { return c.conn }
+// Reconnect runs discovery again. Unless the instance was pinned with
+// DiscoveryOptions, a Hyprland restarted under a new signature is found
+// because the stale signature falls back to the newest live instance.
+func (c *Client) Reconnect() error {
+	before, _ := CurrentInstance()
+	if err := c.Connect(); err != nil {
+		return err
+	}
+	if after, _ := CurrentInstance(); after.EventSocket() != before.EventSocket() {
+		fmt.Printf("Hyprland instance changed: %s\n", after.Signature)
+	}
+	return nil
+}
+
+func (c *Client) setConnection(conn net.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn = conn
+	c.connected = true
+	c.since = time.Now()
+}
+
+func (c *Client) markDisconnected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	if c.connected {
+		c.connected = false
+		c.since = time.Now()
+	}
+}
+
+// Status reports whether socket2 is connected and since when it has been
+// connected or disconnected.
+func (c *Client) Status() (connected bool, since time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected, c.since
+}
+
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *Client) GetConnection() net.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn
+}
 
 func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
 	if c.conn != nil {
 		return c.conn.Close()
 	}
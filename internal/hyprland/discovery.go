@@ -0,0 +1,167 @@
+package hyprland
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Instance is a running Hyprland instance found by Discover. Signature is
+// empty for an instance given by --hyprland-socket, and Socket is set when
+// that names the socket2 file itself.
+type Instance struct {
+	Signature string
+	Dir       string
+	Socket    string
+}
+
+func (i Instance) EventSocket() string {
+	if i.Socket != "" {
+		return i.Socket
+	}
+	return filepath.Join(i.Dir, ".socket2.sock")
+}
+
+func (i Instance) RequestSocket() string { return filepath.Join(i.Dir, ".socket.sock") }
+
+// DiscoveryOptions override how Discover picks an instance.
+type DiscoveryOptions struct {
+	// Socket is an explicit socket2 path or instance directory
+	// (--hyprland-socket). It disables every other lookup.
+	Socket string
+	// Instance selects a signature instead of $HYPRLAND_INSTANCE_SIGNATURE
+	// (--instance).
+	Instance string
+}
+
+// Discover finds the Hyprland instance to talk to. With a signature (from
+// --instance or $HYPRLAND_INSTANCE_SIGNATURE) it tries the current
+// $XDG_RUNTIME_DIR/hypr/<sig> layout, then the legacy /tmp/hypr/<sig> one.
+// Without a signature, or when the one from the environment is stale, it
+// picks the most recently started live instance. The error lists every
+// candidate that was tried.
+func Discover(opts DiscoveryOptions) (Instance, error) {
+	var tried []string
+	probe := func(inst Instance) bool {
+		if err := probeSocket(inst.EventSocket()); err != nil {
+			tried = append(tried, fmt.Sprintf("%s (%v)", inst.EventSocket(), err))
+			return false
+		}
+		return true
+	}
+
+	if opts.Socket != "" {
+		inst := Instance{Dir: opts.Socket}
+		if info, err := os.Stat(opts.Socket); err == nil && !info.IsDir() {
+			inst.Dir, inst.Socket = filepath.Dir(opts.Socket), opts.Socket
+		}
+		if probe(inst) {
+			return inst, nil
+		}
+		return Instance{}, discoveryError(tried)
+	}
+
+	sig := opts.Instance
+	if sig == "" {
+		sig = os.Getenv("HYPRLAND_INSTANCE_SIGNATURE")
+	}
+	if sig != "" {
+		for _, root := range instanceRoots() {
+			inst := Instance{Signature: sig, Dir: filepath.Join(root, sig)}
+			if probe(inst) {
+				return inst, nil
+			}
+		}
+		if opts.Instance != "" {
+			return Instance{}, discoveryError(tried)
+		}
+	}
+
+	type candidate struct {
+		inst    Instance
+		modTime time.Time
+	}
+	var live []candidate
+	for _, root := range instanceRoots() {
+		dirs, err := os.ReadDir(root)
+		if err != nil {
+			tried = append(tried, fmt.Sprintf("%s/* (%v)", root, err))
+			continue
+		}
+		for _, d := range dirs {
+			if !d.IsDir() || d.Name() == sig {
+				continue
+			}
+			inst := Instance{Signature: d.Name(), Dir: filepath.Join(root, d.Name())}
+			info, err := os.Stat(inst.EventSocket())
+			if err != nil || !probe(inst) {
+				continue
+			}
+			live = append(live, candidate{inst: inst, modTime: info.ModTime()})
+		}
+	}
+	if len(live) == 0 {
+		return Instance{}, discoveryError(tried)
+	}
+
+	sort.Slice(live, func(i, j int) bool { return live[i].modTime.After(live[j].modTime) })
+	return live[0].inst, nil
+}
+
+func instanceRoots() []string {
+	var roots []string
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		roots = append(roots, filepath.Join(runtimeDir, "hypr"))
+	}
+	return append(roots, "/tmp/hypr")
+}
+
+func probeSocket(path string) error {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		if _, statErr := os.Stat(path); statErr != nil {
+			return fmt.Errorf("no such socket")
+		}
+		return fmt.Errorf("not accepting connections")
+	}
+	return conn.Close()
+}
+
+func discoveryError(tried []string) error {
+	if len(tried) == 0 {
+		return fmt.Errorf("no Hyprland instance found")
+	}
+	return fmt.Errorf("no Hyprland instance found, tried:\n  %s", strings.Join(tried, "\n  "))
+}
+
+// The instance the event client is connected to. The request client and
+// spawned commands follow it.
+var (
+	currentMu sync.RWMutex
+	current   Instance
+)
+
+func setCurrentInstance(inst Instance) {
+	currentMu.Lock()
+	current = inst
+	currentMu.Unlock()
+
+	// Commands such as hyprctl find the instance through the environment.
+	// An instance given by path has no known signature; the inherited
+	// environment is left alone then.
+	if inst.Signature != "" {
+		os.Setenv("HYPRLAND_INSTANCE_SIGNATURE", inst.Signature)
+	}
+}
+
+// CurrentInstance returns the instance the daemon is connected to, if any.
+func CurrentInstance() (Instance, bool) {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	return current, current.Dir != ""
+}
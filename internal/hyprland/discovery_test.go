@@ -0,0 +1,30 @@
+package hyprland
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestDiscoverExplicitSocket checks that a socket2 path given with
+// --hyprland-socket is used as is, without a signature made up from its
+// directory.
+func TestDiscoverExplicitSocket(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "custom.sock")
+	l := fakeSocket2(t, path, "", make(chan struct{}))
+	defer l.Close()
+
+	inst, err := Discover(DiscoveryOptions{Socket: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := inst.EventSocket(); got != path {
+		t.Errorf("EventSocket() = %q, want %q", got, path)
+	}
+	if got, want := inst.RequestSocket(), filepath.Join(dir, ".socket.sock"); got != want {
+		t.Errorf("RequestSocket() = %q, want %q", got, want)
+	}
+	if inst.Signature != "" {
+		t.Errorf("Signature = %q, want none", inst.Signature)
+	}
+}
@@ -5,16 +5,64 @@ import (
 	"fmt"
 	"hyprtrigger/internal/events"
 	"strings"
+	"time"
+)
+
+const (
+	minReconnectDelay = 250 * time.Millisecond
+	maxReconnectDelay = 30 * time.Second
 )
 
 type Listener struct {
-	client *Client
+	client      *Client
+	onReconnect func()
 }
 
 func NewListener(client *Client) *Listener {
 	return &Listener{client: client}
 }
 
+// OnReconnect sets a function called after every successful reconnection,
+// typically to resync cached state.
+func (l *Listener) OnReconnect(f func()) {
+	l.onReconnect = f
+}
+
+// Run listens until the client is closed. Whenever socket2 closes it
+// reconnects with exponential backoff instead of returning.
+func (l *Listener) Run() {
+	delay := minReconnectDelay
+	for {
+		err := l.Listen()
+		if l.client.isClosed() {
+			return
+		}
+		l.client.markDisconnected()
+		if err != nil {
+			fmt.Printf("Hyprland connection lost: %v\n", err)
+		} else {
+			fmt.Println("Hyprland connection closed")
+		}
+
+		for {
+			time.Sleep(delay)
+			if l.client.isClosed() {
+				return
+			}
+			if err := l.client.Reconnect(); err != nil {
+				delay = min(delay*2, maxReconnectDelay)
+				fmt.Printf("Reconnect failed, retrying in %v: %v\n", delay, err)
+				continue
+			}
+			break
+		}
+		delay = minReconnectDelay
+		if l.onReconnect != nil {
+			l.onReconnect()
+		}
+	}
+}
+
 func (l *Listener) Listen() error {
 	scanner := bufio.NewScanner(l.client.GetConnection())
 
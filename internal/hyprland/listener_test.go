@@ -0,0 +1,117 @@
+package hyprland
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hyprtrigger/internal/events"
+)
+
+// fakeSocket2 serves socket2 at path: every connection, including the
+// probes of Discover, is sent line and kept open until drop is closed.
+func fakeSocket2(t *testing.T, path, line string, drop <-chan struct{}) net.Listener {
+	t.Helper()
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				fmt.Fprintln(conn, line)
+				<-drop
+			}()
+		}
+	}()
+	return l
+}
+
+func waitEvent(t *testing.T, sub *events.Subscription, raw string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case trace := <-sub.C:
+			if trace.Raw == raw {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no %q event received", raw)
+		}
+	}
+}
+
+// TestListenerReconnects closes socket2 under the listener, keeps it away
+// for a few reconnect attempts and checks that Run reconnects with backoff
+// and keeps delivering events.
+func TestListenerReconnects(t *testing.T) {
+	runtimeDir := t.TempDir()
+	dir := filepath.Join(runtimeDir, "hypr", "test")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "test")
+	path := filepath.Join(dir, ".socket2.sock")
+
+	sub := events.DefaultProcessor.Subscribe(16, nil)
+	defer sub.Close()
+
+	drop := make(chan struct{})
+	first := fakeSocket2(t, path, "workspace>>first", drop)
+
+	client := NewClient(DiscoveryOptions{})
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	var reconnects atomic.Int32
+	l := NewListener(client)
+	l.OnReconnect(func() { reconnects.Add(1) })
+	done := make(chan struct{})
+	go func() {
+		l.Run()
+		close(done)
+	}()
+
+	waitEvent(t, sub, "workspace>>first")
+
+	// Hyprland goes away: the connection closes and the socket disappears.
+	close(drop)
+	first.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for connected, _ := client.Status(); connected; connected, _ = client.Status() {
+		if time.Now().After(deadline) {
+			t.Fatal("client still reported as connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(3 * minReconnectDelay)
+
+	second := fakeSocket2(t, path, "workspace>>second", make(chan struct{}))
+	defer second.Close()
+	waitEvent(t, sub, "workspace>>second")
+
+	if connected, _ := client.Status(); !connected {
+		t.Error("client not reported as connected after reconnecting")
+	}
+	if n := reconnects.Load(); n != 1 {
+		t.Errorf("OnReconnect called %d times, want 1", n)
+	}
+
+	client.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after Close")
+	}
+}
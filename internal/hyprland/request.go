@@ -0,0 +1,114 @@
+package hyprland
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 2 * time.Second
+
+// RequestClient talks to Hyprland's request socket (.socket.sock), the same
+// socket hyprctl uses. Hyprland answers one request per connection, so each
+// call dials afresh to the socket of the current instance, so it follows
+// Client.Reconnect.
+type RequestClient struct{}
+
+func NewRequestClient() *RequestClient {
+	return &RequestClient{}
+}
+
+// Request sends a raw request, e.g. "dispatch workspace 2" or "j/clients",
+// and returns the full reply.
+func (c *RequestClient) Request(request string) ([]byte, error) {
+	inst, ok := CurrentInstance()
+	if !ok {
+		return nil, fmt.Errorf("not connected to a Hyprland instance")
+	}
+	conn, err := net.DialTimeout("unix", inst.RequestSocket(), requestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("request socket connection failed: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reply: %w", err)
+	}
+	return reply, nil
+}
+
+// Dispatch runs dispatcher commands such as "setfloating address:0x1234".
+// Several commands are sent as one [[BATCH]] request unless one of them
+// contains the batch separator, in which case they are sent one by one so
+// a window title cannot smuggle in an extra dispatcher.
+func (c *RequestClient) Dispatch(commands ...string) error {
+	requests := make([]string, len(commands))
+	separate := false
+	for i, command := range commands {
+		requests[i] = "dispatch " + command
+		if strings.ContainsAny(command, ";\n") {
+			separate = true
+		}
+	}
+
+	if !separate {
+		return c.Batch(requests...)
+	}
+	for _, request := range requests {
+		if err := c.Batch(request); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Keyword sets a config value at runtime, like `hyprctl keyword`.
+func (c *RequestClient) Keyword(keyword, value string) error {
+	return c.Batch("keyword " + keyword + " " + value)
+}
+
+// Batch sends full requests ("dispatch ...", "keyword ...") in a single
+// round trip and fails unless every one of them answered "ok".
+func (c *RequestClient) Batch(requests ...string) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	request := requests[0]
+	if len(requests) > 1 {
+		request = "[[BATCH]]" + strings.Join(requests, ";")
+	}
+
+	reply, err := c.Request(request)
+	if err != nil {
+		return err
+	}
+	for _, result := range strings.Split(string(reply), "\n\n") {
+		result = strings.TrimSpace(result)
+		if result != "" && result != "ok" {
+			return fmt.Errorf("hyprland: %s", result)
+		}
+	}
+	return nil
+}
+
+// Query runs a JSON query such as "clients" (sent as j/clients) and decodes
+// the reply into v.
+func (c *RequestClient) Query(name string, v any) error {
+	reply, err := c.Request("j/" + name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(reply, v); err != nil {
+		return fmt.Errorf("failed to decode %s reply: %w", name, err)
+	}
+	return nil
+}
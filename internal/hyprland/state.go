@@ -0,0 +1,275 @@
+package hyprland
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"hyprtrigger/internal/events"
+)
+
+type WorkspaceRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Window mirrors an entry of j/clients. Address is stored without the 0x
+// prefix, like events.EventData.WindowID.
+type Window struct {
+	Address      string       `json:"address"`
+	Class        string       `json:"class"`
+	Title        string       `json:"title"`
+	InitialClass string       `json:"initialClass"`
+	InitialTitle string       `json:"initialTitle"`
+	Workspace    WorkspaceRef `json:"workspace"`
+	Monitor      int          `json:"monitor"`
+	Floating     bool         `json:"floating"`
+	Pinned       bool         `json:"pinned"`
+	Pid          int          `json:"pid"`
+}
+
+// Workspace mirrors an entry of j/workspaces.
+type Workspace struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Monitor   string `json:"monitor"`
+	MonitorID int    `json:"monitorID"`
+}
+
+// Monitor mirrors an entry of j/monitors.
+type Monitor struct {
+	ID              int          `json:"id"`
+	Name            string       `json:"name"`
+	Description     string       `json:"description"`
+	ActiveWorkspace WorkspaceRef `json:"activeWorkspace"`
+	Focused         bool         `json:"focused"`
+}
+
+// State is an in-memory model of Hyprland's clients, workspaces and
+// monitors. It is seeded with Sync and then kept current by Update, which
+// the processor calls for every event.
+type State struct {
+	mu           sync.RWMutex
+	windows      map[string]*Window
+	workspaces   map[int]*Workspace
+	monitors     map[string]*Monitor
+	activeWindow string
+}
+
+func NewState() *State {
+	return &State{
+		windows:    make(map[string]*Window),
+		workspaces: make(map[int]*Workspace),
+		monitors:   make(map[string]*Monitor),
+	}
+}
+
+// Sync replaces the whole model with the result of j/clients,
+// j/workspaces and j/monitors.
+func (s *State) Sync(rc *RequestClient) error {
+	var (
+		clients    []Window
+		workspaces []Workspace
+		monitors   []Monitor
+	)
+	if err := rc.Query("clients", &clients); err != nil {
+		return fmt.Errorf("state sync failed: %w", err)
+	}
+	if err := rc.Query("workspaces", &workspaces); err != nil {
+		return fmt.Errorf("state sync failed: %w", err)
+	}
+	if err := rc.Query("monitors", &monitors); err != nil {
+		return fmt.Errorf("state sync failed: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.windows = make(map[string]*Window, len(clients))
+	for i := range clients {
+		w := &clients[i]
+		w.Address = strings.TrimPrefix(w.Address, "0x")
+		s.windows[w.Address] = w
+	}
+	s.workspaces = make(map[int]*Workspace, len(workspaces))
+	for i := range workspaces {
+		s.workspaces[workspaces[i].ID] = &workspaces[i]
+	}
+	s.monitors = make(map[string]*Monitor, len(monitors))
+	for i := range monitors {
+		s.monitors[monitors[i].Name] = &monitors[i]
+	}
+	return nil
+}
+
+// Window implements events.StateTracker.
+func (s *State) Window(address string) (events.WindowInfo, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w, ok := s.windows[strings.TrimPrefix(address, "0x")]
+	if !ok {
+		return events.WindowInfo{}, false
+	}
+	info := events.WindowInfo{
+		Class:         w.Class,
+		Title:         w.Title,
+		InitialClass:  w.InitialClass,
+		InitialTitle:  w.InitialTitle,
+		WorkspaceID:   strconv.Itoa(w.Workspace.ID),
+		WorkspaceName: w.Workspace.Name,
+	}
+	if ws, ok := s.workspaces[w.Workspace.ID]; ok {
+		info.Monitor = ws.Monitor
+	} else {
+		for _, m := range s.monitors {
+			if m.ID == w.Monitor {
+				info.Monitor = m.Name
+			}
+		}
+	}
+	return info, true
+}
+
+// Windows returns a copy of every known window.
+func (s *State) Windows() []Window {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Window, 0, len(s.windows))
+	for _, w := range s.windows {
+		list = append(list, *w)
+	}
+	return list
+}
+
+// Workspaces returns a copy of every known workspace.
+func (s *State) Workspaces() []Workspace {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Workspace, 0, len(s.workspaces))
+	for _, ws := range s.workspaces {
+		list = append(list, *ws)
+	}
+	return list
+}
+
+// Monitors returns a copy of every known monitor.
+func (s *State) Monitors() []Monitor {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Monitor, 0, len(s.monitors))
+	for _, m := range s.monitors {
+		list = append(list, *m)
+	}
+	return list
+}
+
+// ActiveWindow returns the address of the focused window, if known.
+func (s *State) ActiveWindow() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.activeWindow
+}
+
+// Update implements events.StateTracker.
+func (s *State) Update(data *events.EventData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch data.Name {
+	case "openwindow":
+		w := &Window{
+			Address:      data.WindowID,
+			Class:        data.Class,
+			Title:        data.Title,
+			InitialClass: data.InitialClass,
+			InitialTitle: data.InitialTitle,
+			Workspace:    s.workspaceRefByName(data.WorkspaceName),
+		}
+		s.windows[w.Address] = w
+	case "closewindow":
+		delete(s.windows, data.WindowID)
+		if s.activeWindow == data.WindowID {
+			s.activeWindow = ""
+		}
+	case "windowtitlev2":
+		if w, ok := s.windows[data.WindowID]; ok {
+			w.Title = data.Title
+		}
+	case "movewindowv2":
+		if w, ok := s.windows[data.WindowID]; ok {
+			w.Workspace = WorkspaceRef{ID: atoi(data.WorkspaceID), Name: data.WorkspaceName}
+		}
+	case "changefloatingmode":
+		if w, ok := s.windows[data.WindowID]; ok {
+			w.Floating = data.State == "1"
+		}
+	case "pin":
+		if w, ok := s.windows[data.WindowID]; ok {
+			w.Pinned = data.State == "1"
+		}
+	case "activewindowv2":
+		s.activeWindow = data.WindowID
+	case "createworkspacev2":
+		id := atoi(data.WorkspaceID)
+		s.workspaces[id] = &Workspace{ID: id, Name: data.WorkspaceName, Monitor: s.focusedMonitor()}
+	case "destroyworkspacev2":
+		delete(s.workspaces, atoi(data.WorkspaceID))
+	case "renameworkspace":
+		id := atoi(data.WorkspaceID)
+		if ws, ok := s.workspaces[id]; ok {
+			ws.Name = data.WorkspaceName
+		}
+		for _, w := range s.windows {
+			if w.Workspace.ID == id {
+				w.Workspace.Name = data.WorkspaceName
+			}
+		}
+	case "moveworkspacev2":
+		if ws, ok := s.workspaces[atoi(data.WorkspaceID)]; ok {
+			ws.Monitor = data.Monitor
+			if m, ok := s.monitors[data.Monitor]; ok {
+				ws.MonitorID = m.ID
+			}
+		}
+	case "workspacev2":
+		if m, ok := s.monitors[s.focusedMonitor()]; ok {
+			m.ActiveWorkspace = WorkspaceRef{ID: atoi(data.WorkspaceID), Name: data.WorkspaceName}
+		}
+	case "focusedmon", "focusedmonv2":
+		for name, m := range s.monitors {
+			m.Focused = name == data.Monitor
+		}
+	case "monitoraddedv2":
+		s.monitors[data.Monitor] = &Monitor{ID: atoi(data.MonitorID), Name: data.Monitor, Description: data.Description}
+	case "monitorremoved", "monitorremovedv2":
+		delete(s.monitors, data.Monitor)
+	}
+}
+
+func (s *State) workspaceRefByName(name string) WorkspaceRef {
+	for _, ws := range s.workspaces {
+		if ws.Name == name {
+			return WorkspaceRef{ID: ws.ID, Name: ws.Name}
+		}
+	}
+	return WorkspaceRef{ID: atoi(name), Name: name}
+}
+
+func (s *State) focusedMonitor() string {
+	for name, m := range s.monitors {
+		if m.Focused {
+			return name
+		}
+	}
+	return ""
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}